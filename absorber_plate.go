@@ -0,0 +1,39 @@
+// absorber plate: a separate thermal node for the collector's absorber, glazing and frame.
+// The plate receives the absorbed radiation and loses heat to ambient, and is coupled to the collector fluid
+// by a heat transfer coefficient. This delays morning warm-up compared to treating the collector as fluid only.
+package main
+
+type absorberPlate struct {
+	fluidSystem     // no fluid mass; the plate's thermal mass is dryHeatCapacity
+	panelArea       float64
+	panelEfficiency float64
+	fluidHTC        float64 // W/m^2*K; plate to fluid
+}
+
+func (ap *absorberPlate) initialize(fluidOutput IFluidSystem, incidentRadiation variableIntegrator) {
+	ap.heatInComponents = []IComponent{
+		heatAborptionComponent{
+			component: component{
+				name: "Incident Radiation",
+			},
+			efficiency:        ap.panelEfficiency,
+			incidentRadiation: incidentRadiation,
+			surfaceArea:       ap.panelArea,
+		},
+	}
+
+	ap.heatOutComponents = []IComponent{}
+	ap.addEnvironmentalConvectionHeatLossComponent()
+	ap.heatOutComponents = append(ap.heatOutComponents,
+		transferHeatComponentWrapper{
+			component: component{
+				name: "Heat Output",
+			},
+			wrappedComponent: conductanceHeatComponent{
+				conductance: ap.fluidHTC * ap.panelArea,
+				currentTemp: func() float64 { return (*ap).temperature },
+				outputTemp:  func() float64 { return fluidOutput.getTemp() },
+			},
+			output: fluidOutput,
+		})
+}
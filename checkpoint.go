@@ -0,0 +1,242 @@
+// checkpoints snapshot the full simulation state to a file so a run can be resumed,
+// or so several what-if scenarios can be branched from a common warmed-up state
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+const (
+	checkpointFormat  = "heat-transfer-simulation-checkpoint"
+	checkpointVersion = 4
+)
+
+// IStateful can save its state to and restore it from a checkpoint.
+// The state should include everything needed to continue the run, e.g. temperatures and controller states.
+type IStateful interface {
+	getState() map[string]float64
+	setState(state map[string]float64) error
+}
+
+// IDataRecorder records data series that are plotted after the run
+type IDataRecorder interface {
+	getName() string
+	getData() map[string]*[]opts.LineData
+	setData(data map[string]*[]opts.LineData)
+}
+
+// dataRecorder holds a controller's recorded series; controllers embed it and add a point every step
+type dataRecorder struct {
+	data map[string]*[]opts.LineData
+}
+
+func (r *dataRecorder) getData() map[string]*[]opts.LineData {
+	return r.data
+}
+
+func (r *dataRecorder) setData(data map[string]*[]opts.LineData) {
+	r.data = data
+}
+
+func (r *dataRecorder) addDataPoint(name string, value float64) {
+	if r.data == nil {
+		r.data = map[string]*[]opts.LineData{}
+	}
+	if _, ok := r.data[name]; !ok {
+		r.data[name] = &[]opts.LineData{}
+	}
+	(*r.data[name]) = append((*r.data[name]), opts.LineData{Value: value})
+}
+
+type IStatefulSystem interface {
+	ISystem
+	IStateful
+	setData(data map[string]*[]opts.LineData)
+}
+
+// checkpoint is the file format. The format and version are checked before anything else is decoded.
+type checkpoint struct {
+	Format      string                 `json:"format"`
+	Version     int                    `json:"version"`
+	Time        float64                `json:"time"`     // s; simulated time at the end of the last completed step
+	TimeStep    float64                `json:"timeStep"` // s
+	TimeSeries  []float64              `json:"timeSeries"`
+	Systems     []systemCheckpoint     `json:"systems"`
+	Controllers []controllerCheckpoint `json:"controllers"`
+}
+
+type controllerCheckpoint struct {
+	Name  string               `json:"name"`
+	State map[string]float64   `json:"state"`
+	Data  map[string][]float64 `json:"data"`
+}
+
+type systemCheckpoint struct {
+	Name              string               `json:"name"`
+	State             map[string]float64   `json:"state"`
+	TemperatureSeries []float64            `json:"temperatureSeries"`
+	Data              map[string][]float64 `json:"data"`
+}
+
+// createCheckpoint snapshots the systems and the results recorded so far
+func (sim *simulation) createCheckpoint(result simulationResult) (checkpoint, error) {
+	cp := checkpoint{
+		Format:     checkpointFormat,
+		Version:    checkpointVersion,
+		Time:       result.stopTime,
+		TimeStep:   sim.timeStep,
+		TimeSeries: result.timeSeries,
+	}
+	for _, sys := range sim.systems {
+		stateful, ok := sys.(IStatefulSystem)
+		if !ok {
+			return checkpoint{}, fmt.Errorf("system %s does not support checkpoints", sys.getName())
+		}
+		sysCheckpoint := systemCheckpoint{
+			Name:              sys.getName(),
+			State:             stateful.getState(),
+			TemperatureSeries: lineDataToFloats(result.systemsSeries[sys.getName()]),
+			Data:              map[string][]float64{},
+		}
+		for name, series := range sys.getData() {
+			sysCheckpoint.Data[name] = lineDataToFloats(*series)
+		}
+		cp.Systems = append(cp.Systems, sysCheckpoint)
+	}
+	for _, controller := range sim.controllers {
+		stateful, ok := controller.(IStateful)
+		if !ok {
+			return checkpoint{}, fmt.Errorf("controller %s does not support checkpoints", controller.getName())
+		}
+		controllerCp := controllerCheckpoint{
+			Name:  controller.getName(),
+			State: stateful.getState(),
+			Data:  map[string][]float64{},
+		}
+		if recorder, ok := controller.(IDataRecorder); ok {
+			for name, series := range recorder.getData() {
+				controllerCp.Data[name] = lineDataToFloats(*series)
+			}
+		}
+		cp.Controllers = append(cp.Controllers, controllerCp)
+	}
+	return cp, nil
+}
+
+// restoreCheckpoint sets every system's state from the checkpoint. The next run continues after the checkpoint's time.
+func (sim *simulation) restoreCheckpoint(cp checkpoint) error {
+	if math.Abs(cp.TimeStep-sim.timeStep) > float64EqualityThreshold {
+		return fmt.Errorf("checkpoint time step %gs does not match simulation time step %gs", cp.TimeStep, sim.timeStep)
+	}
+
+	sysCheckpoints := map[string]systemCheckpoint{}
+	for _, sysCheckpoint := range cp.Systems {
+		sysCheckpoints[sysCheckpoint.Name] = sysCheckpoint
+	}
+	for _, sys := range sim.systems {
+		stateful, ok := sys.(IStatefulSystem)
+		if !ok {
+			return fmt.Errorf("system %s does not support checkpoints", sys.getName())
+		}
+		sysCheckpoint, ok := sysCheckpoints[sys.getName()]
+		if !ok {
+			return fmt.Errorf("checkpoint has no state for system %s", sys.getName())
+		}
+		if err := stateful.setState(sysCheckpoint.State); err != nil {
+			return fmt.Errorf("system %s: %w", sys.getName(), err)
+		}
+		stateful.setData(checkpointDataToLineData(sysCheckpoint.Data))
+	}
+
+	controllerCheckpoints := map[string]controllerCheckpoint{}
+	for _, controllerCp := range cp.Controllers {
+		controllerCheckpoints[controllerCp.Name] = controllerCp
+	}
+	for _, controller := range sim.controllers {
+		stateful, ok := controller.(IStateful)
+		if !ok {
+			return fmt.Errorf("controller %s does not support checkpoints", controller.getName())
+		}
+		controllerCp, ok := controllerCheckpoints[controller.getName()]
+		if !ok {
+			return fmt.Errorf("checkpoint has no state for controller %s", controller.getName())
+		}
+		if err := stateful.setState(controllerCp.State); err != nil {
+			return fmt.Errorf("controller %s: %w", controller.getName(), err)
+		}
+		if recorder, ok := controller.(IDataRecorder); ok {
+			recorder.setData(checkpointDataToLineData(controllerCp.Data))
+		}
+	}
+
+	sim.resumeFrom = &cp
+	return nil
+}
+
+func writeCheckpoint(cp checkpoint, fileName string) error {
+	data, err := json.Marshal(cp)
+	if err != nil {
+		return err
+	}
+	// write to a temporary file first so an interrupted write doesn't corrupt the previous checkpoint
+	tmpFileName := fileName + ".tmp"
+	if err := os.WriteFile(tmpFileName, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmpFileName, fileName)
+}
+
+func readCheckpoint(fileName string) (checkpoint, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return checkpoint{}, err
+	}
+
+	var header struct {
+		Format  string `json:"format"`
+		Version int    `json:"version"`
+	}
+	if err := json.Unmarshal(data, &header); err != nil || header.Format != checkpointFormat {
+		return checkpoint{}, errors.New("file is not a simulation checkpoint")
+	}
+	if header.Version != checkpointVersion {
+		return checkpoint{}, fmt.Errorf("checkpoint version %d is not supported, expected version %d", header.Version, checkpointVersion)
+	}
+
+	var cp checkpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return checkpoint{}, err
+	}
+	return cp, nil
+}
+
+func checkpointDataToLineData(checkpointData map[string][]float64) map[string]*[]opts.LineData {
+	data := map[string]*[]opts.LineData{}
+	for name, series := range checkpointData {
+		lineData := floatsToLineData(series)
+		data[name] = &lineData
+	}
+	return data
+}
+
+func lineDataToFloats(lineData []opts.LineData) []float64 {
+	values := make([]float64, len(lineData))
+	for i, point := range lineData {
+		values[i], _ = point.Value.(float64)
+	}
+	return values
+}
+
+func floatsToLineData(values []float64) []opts.LineData {
+	lineData := make([]opts.LineData, len(values))
+	for i, value := range values {
+		lineData[i] = opts.LineData{Value: value}
+	}
+	return lineData
+}
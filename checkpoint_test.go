@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newCheckpointTestSimulation(duration float64) (*simulation, *fluidSystem) {
+	fs := &fluidSystem{
+		name:               "System",
+		exposedSurfaceArea: 1.0,
+		ambientTemp:        15.0,
+		ambientHTC:         10.0,
+		fluidMass:          1.0,
+		temperature:        20.0,
+		heatInComponents: []IComponent{
+			mockHeatComponent{
+				mockComponent: mockComponent{name: "Heat In"},
+				heat:          1000.0,
+			},
+		},
+	}
+	fs.addEnvironmentalConvectionHeatLossComponent()
+	return &simulation{systems: []ISystem{fs}, timeStep: 1.0, duration: duration}, fs
+}
+
+func TestCheckpoint_Resume(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "checkpoint.json")
+
+	// uninterrupted reference run
+	reference, referenceSystem := newCheckpointTestSimulation(20.0)
+	referenceResult := reference.run(context.Background())
+
+	// first half, writing a checkpoint at 10s
+	first, _ := newCheckpointTestSimulation(10.0)
+	first.checkpointInterval = 10.0
+	first.checkpointFile = fileName
+	first.run(context.Background())
+
+	// resume into freshly built systems
+	cp, err := readCheckpoint(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resumed, resumedSystem := newCheckpointTestSimulation(20.0)
+	if err := resumed.restoreCheckpoint(cp); err != nil {
+		t.Fatal(err)
+	}
+	resumedResult := resumed.run(context.Background())
+
+	if resumedSystem.temperature != referenceSystem.temperature {
+		t.Errorf("expected resumed temperature %v, got %v", referenceSystem.temperature, resumedSystem.temperature)
+	}
+	if len(resumedResult.timeSeries) != len(referenceResult.timeSeries) {
+		t.Errorf("expected %v recorded steps, got %v", len(referenceResult.timeSeries), len(resumedResult.timeSeries))
+	}
+	if len(*resumedSystem.getData()["Heat In"]) != len(*referenceSystem.getData()["Heat In"]) {
+		t.Errorf("expected restored data series to continue")
+	}
+}
+
+func TestCheckpoint_UnsupportedVersion(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "checkpoint.json")
+	if err := os.WriteFile(fileName, []byte(`{"format":"heat-transfer-simulation-checkpoint","version":0}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := readCheckpoint(fileName)
+	if err == nil || !strings.Contains(err.Error(), "version 0 is not supported") {
+		t.Errorf("expected unsupported version error, got %v", err)
+	}
+
+	if err := os.WriteFile(fileName, []byte(`{"temperature":20}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := readCheckpoint(fileName); err == nil {
+		t.Error("expected error for a file that is not a checkpoint")
+	}
+}
@@ -0,0 +1,288 @@
+// collector array: many panels connected as parallel branches of panels in series.
+// The loop's flow is split between the branches by their hydraulic resistance, including the supply and return headers,
+// so a direct return layout shows the flow imbalance that a reverse return (Tichelmann) layout avoids.
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const (
+	// the return header leaves from the same end the supply enters, so the nearest branch has the shortest path
+	directReturn = "direct"
+	// the return header leaves from the far end, so every branch has the same path length
+	reverseReturn = "reverse"
+
+	arraySolverIterations = 30
+	arraySolverTolerance  = 1e-3 // Pa; largest pressure imbalance allowed between branches
+)
+
+type collectorArray struct {
+	name         string
+	returnLayout string
+	collector    IHydraulicResistance // a single collector
+	header       IHydraulicResistance // a header segment between neighbouring branches, on both the supply and return side
+	fluid        *fluid
+	flowMass     variableIntegrator // kg/s; total flow through the array
+	branches     [][]*solarPanel    // branches[b][s] is the s-th panel in series in branch b
+	// the flow split, solved every step
+	branchFlows         []float64 // kg/s
+	branchFractions     []float64 // share of the total flow in each branch, the starting guess for the next solve
+	pressureCoefficient float64   // Pa/(m^3/s)^2; the array's pressure drop is kQ² at the current split
+	dataRecorder
+}
+
+// build creates the array's panels with newPanel, passing a suffix of the branch and position,
+// e.g. "2-3" for the third panel in series in the second branch
+func (a *collectorArray) build(branchCount int, seriesCount int, newPanel func(suffix string) *solarPanel) {
+	a.branches = make([][]*solarPanel, branchCount)
+	a.branchFlows = make([]float64, branchCount)
+	a.branchFractions = make([]float64, branchCount)
+	for b := range a.branches {
+		for s := 0; s < seriesCount; s++ {
+			a.branches[b] = append(a.branches[b], newPanel(fmt.Sprintf("%d-%d", b+1, s+1)))
+		}
+		a.branchFractions[b] = 1 / float64(branchCount)
+	}
+}
+
+func (a *collectorArray) getName() string {
+	return a.name
+}
+
+func (a *collectorArray) getPanels() []*solarPanel {
+	panels := []*solarPanel{}
+	for _, branch := range a.branches {
+		panels = append(panels, branch...)
+	}
+	return panels
+}
+
+// getOutletTemp is the mixed temperature of the branches leaving the array
+func (a *collectorArray) getOutletTemp() float64 {
+	totalFlow, weightedTemp, sumTemp := 0.0, 0.0, 0.0
+	for b, branch := range a.branches {
+		temp := branch[len(branch)-1].temperature
+		totalFlow += a.branchFlows[b]
+		weightedTemp += a.branchFlows[b] * temp
+		sumTemp += temp
+	}
+	if totalFlow <= 0 {
+		return sumTemp / float64(len(a.branches))
+	}
+	return weightedTemp / totalFlow
+}
+
+// initialize carries each branch's flow through its panels in series.
+// A panel gains ṁc(Tᵤₚ - T) from the fluid entering it, where Tᵤₚ is the previous panel's or the array inlet's temperature.
+func (a *collectorArray) initialize(inletTemp variableIntegrator) {
+	for b, branch := range a.branches {
+		upstreamTemp := inletTemp
+		for _, sp := range branch {
+			sp.addInflowComponent("Inflow", func() float64 { return a.branchFlows[b] }, upstreamTemp)
+			upstreamTemp = func() float64 { return sp.temperature }
+		}
+	}
+}
+
+// connectTank sends flowMass of the array's outflow to the tank, either directly or through a heat exchanger,
+// and returns the temperature of the fluid coming back. The fluid leaves the heat exchanger at Tₒᵤₜ - q/ṁc.
+func (a *collectorArray) connectTank(tank *fluidSystem, flowMass variableIntegrator, arrangement string, ua float64, tankFlowMass variableIntegrator) variableIntegrator {
+	outletSpecificHeat := func() float64 { return a.fluid.getSpecificHeat(a.getOutletTemp()) }
+	tankTemp := func() float64 { return tank.temperature }
+	if arrangement == "" {
+		tank.heatInComponents = append(tank.heatInComponents, heatCapacityFluidComponent{
+			component: component{
+				name: "Collector Inflow",
+			},
+			flowMass:     flowMass,
+			specificHeat: outletSpecificHeat,
+			currentTemp:  a.getOutletTemp,
+			outputTemp:   tankTemp,
+		})
+		return tankTemp
+	}
+
+	exchanger := heatExchangerComponent{
+		component: component{
+			name: "Heat Exchanger Input",
+		},
+		arrangement:        arrangement,
+		ua:                 ua,
+		flowMass:           flowMass,
+		specificHeat:       outletSpecificHeat,
+		outputFlowMass:     tankFlowMass,
+		outputSpecificHeat: func() float64 { return tank.getSpecificHeat() },
+		currentTemp:        a.getOutletTemp,
+		outputTemp:         tankTemp,
+	}
+	tank.heatInComponents = append(tank.heatInComponents, exchanger)
+	return func() float64 {
+		capacityRate := flowMass() * outletSpecificHeat()
+		if capacityRate <= 0 {
+			return a.getOutletTemp()
+		}
+		return a.getOutletTemp() - exchanger.getHeat()/capacityRate
+	}
+}
+
+func (a *collectorArray) update(time float64, timeStep float64) {
+	a.splitFlow(a.flowMass())
+	for b, flow := range a.branchFlows {
+		a.addDataPoint(fmt.Sprintf("Branch %d Flow", b+1), flow)
+	}
+}
+
+// splitFlow solves the branch flows for a total mass flow, and updates the array's pressure coefficient
+func (a *collectorArray) splitFlow(flowMass float64) {
+	temp := a.getOutletTemp()
+	density := a.fluid.getDensity(temp)
+	flowVolume := flowMass / density
+	if flowVolume <= 0 {
+		for b := range a.branchFlows {
+			a.branchFlows[b] = 0.0
+		}
+		return
+	}
+
+	branchVolumes, pressureDrop := a.solveFlowSplit(flowVolume, temp)
+	for b, branchVolume := range branchVolumes {
+		a.branchFlows[b] = branchVolume * density
+		a.branchFractions[b] = branchVolume / flowVolume
+	}
+	a.pressureCoefficient = pressureDrop / (flowVolume * flowVolume)
+}
+
+// solveFlowSplit runs Newton iteration on the branch volume flows qᵦ and the array's pressure drop P.
+// Every branch satisfies P - Sᵦ - Rᵦ = Δpᵦ(qᵦ), where Sᵦ and Rᵦ are the supply and return header drops to the branch,
+// and the branch flows sum to the total flow.
+func (a *collectorArray) solveFlowSplit(flowVolume float64, temp float64) ([]float64, float64) {
+	n := len(a.branches)
+	x := make([]float64, n+1)
+	for b := range a.branches {
+		x[b] = a.branchFractions[b] * flowVolume
+	}
+	x[n] = a.branchPressureDrop(0, x[0], temp) + a.headerPressureDrops(x[:n], temp)[0]
+
+	residuals := a.flowSplitResiduals(x, flowVolume, temp)
+	for i := 0; i < arraySolverIterations && maxAbs(residuals) > arraySolverTolerance; i++ {
+		// build the jacobian by perturbing one unknown at a time
+		jacobian := make([][]float64, n+1)
+		for row := range jacobian {
+			jacobian[row] = make([]float64, n+1)
+		}
+		for col := range x {
+			perturbation := flowVolume * 1e-6
+			if col == n {
+				perturbation = 1e-3
+			}
+			perturbed := append([]float64{}, x...)
+			perturbed[col] += perturbation
+			perturbedResiduals := a.flowSplitResiduals(perturbed, flowVolume, temp)
+			for row := range residuals {
+				jacobian[row][col] = (perturbedResiduals[row] - residuals[row]) / perturbation
+			}
+		}
+
+		negResiduals := make([]float64, len(residuals))
+		for row, r := range residuals {
+			negResiduals[row] = -r
+		}
+		delta, err := solveLinearSystem(jacobian, negResiduals)
+		if err != nil {
+			break
+		}
+		for col := range x {
+			x[col] += delta[col]
+		}
+		residuals = a.flowSplitResiduals(x, flowVolume, temp)
+	}
+	return x[:n], x[n]
+}
+
+// flowSplitResiduals are each branch's pressure imbalance in Pa, and the flow imbalance scaled to the same order
+func (a *collectorArray) flowSplitResiduals(x []float64, flowVolume float64, temp float64) []float64 {
+	n := len(a.branches)
+	branchVolumes, pressureDrop := x[:n], x[n]
+	headerDrops := a.headerPressureDrops(branchVolumes, temp)
+
+	residuals := make([]float64, n+1)
+	totalVolume := 0.0
+	for b, branchVolume := range branchVolumes {
+		residuals[b] = pressureDrop - headerDrops[b] - a.branchPressureDrop(b, branchVolume, temp)
+		totalVolume += branchVolume
+	}
+	residuals[n] = (totalVolume - flowVolume) / flowVolume * math.Max(pressureDrop, 1.0)
+	return residuals
+}
+
+// headerPressureDrops is Sᵦ + Rᵦ for every branch. The supply enters at the first branch;
+// the return leaves at the first branch for a direct return, and at the last branch for a reverse return.
+func (a *collectorArray) headerPressureDrops(branchVolumes []float64, temp float64) []float64 {
+	n := len(branchVolumes)
+	// downstream[k] is the flow in the supply header segment between branch k and k+1
+	downstream := make([]float64, n)
+	upstream := make([]float64, n)
+	cumulative := 0.0
+	for k := 0; k < n; k++ {
+		cumulative += branchVolumes[k]
+		upstream[k] = cumulative
+	}
+	for k := 0; k < n; k++ {
+		downstream[k] = cumulative - upstream[k]
+	}
+
+	drops := make([]float64, n)
+	supplyDrop := 0.0
+	for b := 0; b < n; b++ {
+		if b > 0 {
+			supplyDrop += signedPressureDrop(a.header, downstream[b-1], a.fluid, temp)
+		}
+		drops[b] = supplyDrop
+	}
+	if a.returnLayout == reverseReturn {
+		returnDrop := 0.0
+		for b := n - 1; b >= 0; b-- {
+			drops[b] += returnDrop
+			if b > 0 {
+				returnDrop += signedPressureDrop(a.header, upstream[b-1], a.fluid, temp)
+			}
+		}
+	} else {
+		// the return header mirrors the supply header
+		for b := range drops {
+			drops[b] *= 2
+		}
+	}
+	return drops
+}
+
+func (a *collectorArray) branchPressureDrop(branch int, branchVolume float64, temp float64) float64 {
+	return float64(len(a.branches[branch])) * signedPressureDrop(a.collector, branchVolume, a.fluid, temp)
+}
+
+// getPressureDrop is the array's pressure drop at the last solved flow split, so the pump doesn't re-solve the split
+func (a *collectorArray) getPressureDrop(flowVolume float64, f *fluid, temp float64) float64 {
+	if flowVolume <= 0 {
+		return 0.0
+	}
+	return a.pressureCoefficient * flowVolume * flowVolume
+}
+
+// signedPressureDrop lets the solver try reverse flows while it iterates
+func signedPressureDrop(r IHydraulicResistance, flowVolume float64, f *fluid, temp float64) float64 {
+	if flowVolume < 0 {
+		return -r.getPressureDrop(-flowVolume, f, temp)
+	}
+	return r.getPressureDrop(flowVolume, f, temp)
+}
+
+// the flow split is solved every step, so the array has no state of its own
+func (a *collectorArray) getState() map[string]float64 {
+	return map[string]float64{}
+}
+
+func (a *collectorArray) setState(state map[string]float64) error {
+	return nil
+}
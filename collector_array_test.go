@@ -0,0 +1,86 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func newTestCollectorArray(returnLayout string, branchCount int, seriesCount int) *collectorArray {
+	a := &collectorArray{
+		name:         "CollectorArray",
+		returnLayout: returnLayout,
+		collector:    quadraticResistance{component{"Collector"}, 3000, 2e-4},
+		header:       pipeResistance{component{"Header"}, 1.2, 0.022, 1.5e-6, 0},
+		fluid:        fluids[waterFluidName],
+		flowMass:     mockVariableIntegrator(0.3),
+	}
+	a.build(branchCount, seriesCount, func(suffix string) *solarPanel {
+		return &solarPanel{fluidSystem: fluidSystem{name: "SolarPanel" + suffix, fluid: fluids[waterFluidName], temperature: 40}}
+	})
+	return a
+}
+
+func TestCollectorArrayFlowSplit(t *testing.T) {
+	tests := []struct {
+		name         string
+		returnLayout string
+	}{
+		{name: "Direct return", returnLayout: directReturn},
+		{name: "Reverse return", returnLayout: reverseReturn},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			a := newTestCollectorArray(tt.returnLayout, 4, 3)
+			a.splitFlow(0.3)
+
+			total := 0.0
+			for _, flow := range a.branchFlows {
+				total += flow
+			}
+			if math.Abs(total-0.3) > 1e-6 {
+				t.Errorf("expected the branch flows to sum to 0.3 kg/s, got %v", total)
+			}
+
+			first, last := a.branchFlows[0], a.branchFlows[len(a.branchFlows)-1]
+			if tt.returnLayout == directReturn && first <= last {
+				t.Errorf("expected the nearest branch to get the most flow with a direct return, got %v", a.branchFlows)
+			}
+			if tt.returnLayout == reverseReturn && math.Abs(first-last) > 1e-6 {
+				t.Errorf("expected the end branches to get equal flow with a reverse return, got %v", a.branchFlows)
+			}
+		})
+	}
+}
+
+func TestCollectorArrayPressureDrop(t *testing.T) {
+	// a single branch has no headers, so its pressure drop is the collectors' in series
+	a := newTestCollectorArray(directReturn, 1, 3)
+	a.splitFlow(0.3)
+	flowVolume := 0.3 / fluids[waterFluidName].getDensity(40)
+	expected := 3 * 3000 * math.Pow(flowVolume/2e-4, 2)
+	if drop := a.getPressureDrop(flowVolume, a.fluid, 40); math.Abs(drop-expected) > 1e-3 {
+		t.Errorf("expected %v Pa, got %v Pa", expected, drop)
+	}
+}
+
+func TestCollectorArrayConservesEnergy(t *testing.T) {
+	// the heat carried into the panels and the heat delivered to the tank cancel out
+	a := newTestCollectorArray(directReturn, 2, 3)
+	tank := fluidSystem{name: "StorageTank", fluid: fluids[waterFluidName], temperature: 20}
+	for i, sp := range a.getPanels() {
+		sp.temperature = 30 + float64(i)
+	}
+	a.initialize(a.connectTank(&tank, a.flowMass, "", 0, nil))
+	a.splitFlow(0.3)
+
+	total := 0.0
+	for _, sys := range append(a.getPanels(), &solarPanel{fluidSystem: tank}) {
+		for _, comp := range sys.heatInComponents {
+			total += comp.(IHeatComponent).getHeat()
+		}
+	}
+	// the specific heat varies a little between the panels' temperatures
+	if math.Abs(total) > 5 {
+		t.Errorf("expected the advected heat to sum to zero, got %v W", total)
+	}
+}
@@ -4,6 +4,13 @@
 // To make them both generic and allow them to depend on variable values, components define variableIntegrator methods which the caller defines
 package main
 
+import "math"
+
+const (
+	stefanBoltzmann = 5.670374419e-8 // W/(m^2*K^4)
+	celsiusToKelvin = 273.15
+)
+
 type variableIntegrator func() float64
 
 // component can be used for all types of components that matter to a system.
@@ -28,7 +35,7 @@ type IHeatComponent interface {
 
 type ambientConvectionHeatComponent struct {
 	component
-	ambientHTC  float64
+	ambientHTC  variableIntegrator
 	surfaceArea float64
 	currentTemp variableIntegrator
 	ambientTemp variableIntegrator
@@ -36,7 +43,37 @@ type ambientConvectionHeatComponent struct {
 
 func (c ambientConvectionHeatComponent) getHeat() float64 {
 	// q = hAΔT
-	return c.ambientHTC * c.surfaceArea * (c.currentTemp() - c.ambientTemp())
+	return c.ambientHTC() * c.surfaceArea * (c.currentTemp() - c.ambientTemp())
+}
+
+// radiationHeatComponent is long-wave radiation exchange with the sky and the surroundings.
+// The surface sees the sky through skyViewFactor and the surroundings through the rest of its view.
+type radiationHeatComponent struct {
+	component
+	emissivity       float64
+	surfaceArea      float64
+	skyViewFactor    float64
+	currentTemp      variableIntegrator // Celsius
+	skyTemp          variableIntegrator // Celsius
+	surroundingsTemp variableIntegrator // Celsius
+}
+
+func (c radiationHeatComponent) getHeat() float64 {
+	// q = εσA(F(T⁴ - Tₛₖᵧ⁴) + (1 - F)(T⁴ - Tₛᵤᵣ⁴)), with temperatures in Kelvin
+	t4 := math.Pow(c.currentTemp()+celsiusToKelvin, 4)
+	sky4 := math.Pow(c.skyTemp()+celsiusToKelvin, 4)
+	surroundings4 := math.Pow(c.surroundingsTemp()+celsiusToKelvin, 4)
+	return c.emissivity * stefanBoltzmann * c.surfaceArea * (c.skyViewFactor*(t4-sky4) + (1-c.skyViewFactor)*(t4-surroundings4))
+}
+
+// heatRateComponent is a heat rate given directly, e.g. a room's internal gains
+type heatRateComponent struct {
+	component
+	heat variableIntegrator // W
+}
+
+func (c heatRateComponent) getHeat() float64 {
+	return c.heat()
 }
 
 type heatAborptionComponent struct {
@@ -51,17 +88,30 @@ func (c heatAborptionComponent) getHeat() float64 {
 	return c.efficiency * c.incidentRadiation() * c.surfaceArea
 }
 
+// conductanceHeatComponent is heat transfer through a fixed conductance, e.g. from an absorber plate to its fluid
+type conductanceHeatComponent struct {
+	component
+	conductance float64 // W/K; UA
+	currentTemp variableIntegrator
+	outputTemp  variableIntegrator
+}
+
+func (c conductanceHeatComponent) getHeat() float64 {
+	// q = UAΔT
+	return c.conductance * (c.currentTemp() - c.outputTemp())
+}
+
 type heatCapacityFluidComponent struct {
 	component
 	flowMass     variableIntegrator // kg
-	specificHeat float64
+	specificHeat variableIntegrator // J/(kg*K)
 	currentTemp  variableIntegrator
 	outputTemp   variableIntegrator
 }
 
 func (c heatCapacityFluidComponent) getHeat() float64 {
 	// q = ṁCΔT
-	return c.flowMass() * c.specificHeat * (c.currentTemp() - c.outputTemp())
+	return c.flowMass() * c.specificHeat() * (c.currentTemp() - c.outputTemp())
 }
 
 // transferHeatComponentWrapper is more complex than a simple component.
@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"testing"
 
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -15,7 +16,7 @@ func mockVariableIntegrator(value float64) variableIntegrator {
 func TestAmbientConvectionHeatComponent(t *testing.T) {
 	component := ambientConvectionHeatComponent{
 		component:   component{name: "Ambient Convection"},
-		ambientHTC:  10.0,
+		ambientHTC:  mockVariableIntegrator(10.0),
 		surfaceArea: 5.0,
 		currentTemp: mockVariableIntegrator(100.0),
 		ambientTemp: mockVariableIntegrator(20.0),
@@ -27,6 +28,25 @@ func TestAmbientConvectionHeatComponent(t *testing.T) {
 	}
 }
 
+func TestRadiationHeatComponent(t *testing.T) {
+	component := radiationHeatComponent{
+		component:        component{name: "Radiation"},
+		emissivity:       0.9,
+		surfaceArea:      2.0,
+		skyViewFactor:    0.75,
+		currentTemp:      mockVariableIntegrator(90.0),
+		skyTemp:          mockVariableIntegrator(-10.0),
+		surroundingsTemp: mockVariableIntegrator(10.0),
+	}
+
+	// q = εσA(F(T⁴ - Tₛₖᵧ⁴) + (1 - F)(T⁴ - Tₛᵤᵣ⁴))
+	t4 := math.Pow(363.15, 4)
+	expectedHeat := 0.9 * stefanBoltzmann * 2.0 * (0.75*(t4-math.Pow(263.15, 4)) + 0.25*(t4-math.Pow(283.15, 4)))
+	if heat := component.getHeat(); math.Abs(heat-expectedHeat) > 1e-9 {
+		t.Errorf("expected %v, got %v", expectedHeat, heat)
+	}
+}
+
 func TestHeatAborptionComponent(t *testing.T) {
 	component := heatAborptionComponent{
 		component:         component{name: "Heat Absorption"},
@@ -45,7 +65,7 @@ func TestHeatCapacityFluidComponent(t *testing.T) {
 	component := heatCapacityFluidComponent{
 		component:    component{name: "Heat Capacity Fluid"},
 		flowMass:     mockVariableIntegrator(2.0),
-		specificHeat: 4.18,
+		specificHeat: mockVariableIntegrator(4.18),
 		currentTemp:  mockVariableIntegrator(80.0),
 		outputTemp:   mockVariableIntegrator(60.0),
 	}
@@ -56,6 +76,74 @@ func TestHeatCapacityFluidComponent(t *testing.T) {
 	}
 }
 
+func TestConductanceHeatComponent(t *testing.T) {
+	component := conductanceHeatComponent{
+		component:   component{name: "Conductance"},
+		conductance: 600.0,
+		currentTemp: mockVariableIntegrator(50.0),
+		outputTemp:  mockVariableIntegrator(45.0),
+	}
+
+	expectedHeat := 3000.0 // q = UAΔT = 600 * (50 - 45)
+	if heat := component.getHeat(); heat != expectedHeat {
+		t.Errorf("expected %v, got %v", expectedHeat, heat)
+	}
+}
+
+func TestHeatExchangerComponent(t *testing.T) {
+	tests := []struct {
+		name         string
+		arrangement  string
+		outputFlow   float64
+		expectedHeat float64
+	}{
+		{
+			// C = 0.1 * 4000 = 400 W/K, NTU = 1, ε = 1 - e⁻¹
+			name:         "Immersed Coil",
+			arrangement:  immersedCoilHeatExchanger,
+			expectedHeat: (1 - math.Exp(-1)) * 400.0 * 40.0,
+		},
+		{
+			// balanced counterflow: ε = NTU/(1 + NTU)
+			name:         "Balanced Counterflow",
+			arrangement:  counterflowHeatExchanger,
+			outputFlow:   0.1,
+			expectedHeat: 0.5 * 400.0 * 40.0,
+		},
+		{
+			// C_min = 400 W/K, Cr = 0.5
+			name:         "Unbalanced Counterflow",
+			arrangement:  counterflowHeatExchanger,
+			outputFlow:   0.2,
+			expectedHeat: (1 - math.Exp(-0.5)) / (1 - 0.5*math.Exp(-0.5)) * 400.0 * 40.0,
+		},
+		{
+			name:         "No Flow On Other Side",
+			arrangement:  counterflowHeatExchanger,
+			outputFlow:   0.0,
+			expectedHeat: 0.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			component := heatExchangerComponent{
+				component:          component{name: "Heat Exchanger"},
+				arrangement:        tt.arrangement,
+				ua:                 400.0,
+				flowMass:           mockVariableIntegrator(0.1),
+				specificHeat:       mockVariableIntegrator(4000.0),
+				outputFlowMass:     mockVariableIntegrator(tt.outputFlow),
+				outputSpecificHeat: mockVariableIntegrator(4000.0),
+				currentTemp:        mockVariableIntegrator(60.0),
+				outputTemp:         mockVariableIntegrator(20.0),
+			}
+			if heat := component.getHeat(); math.Abs(heat-tt.expectedHeat) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.expectedHeat, heat)
+			}
+		})
+	}
+}
+
 type mockFluidSystem struct {
 	receivedHeat float64
 }
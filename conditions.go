@@ -0,0 +1,87 @@
+// conditions are checked after every simulation step. The same conditions are used both to stop the simulation early
+// and to log time-stamped events.
+package main
+
+import (
+	"fmt"
+	"math"
+	"time"
+)
+
+// simulationState is the state conditions are checked against
+type simulationState struct {
+	time             float64 // s
+	timeStep         float64 // s
+	wallClockElapsed time.Duration
+	systems          []ISystem
+	previousTemps    []float64 // system temperatures before the current step, in the same order as systems
+}
+
+type ICondition interface {
+	check(state simulationState) bool
+	describe() string
+}
+
+// temperatureThresholdCondition triggers when a system's temperature crosses the threshold in either direction
+type temperatureThresholdCondition struct {
+	systemName string
+	threshold  float64 // Celsius
+	rising     bool    // direction of the last crossing
+}
+
+func (c *temperatureThresholdCondition) check(state simulationState) bool {
+	for i, sys := range state.systems {
+		if sys.getName() != c.systemName {
+			continue
+		}
+		previous, current := state.previousTemps[i], sys.getTemp()
+		if previous < c.threshold && current >= c.threshold {
+			c.rising = true
+			return true
+		}
+		if previous > c.threshold && current <= c.threshold {
+			c.rising = false
+			return true
+		}
+	}
+	return false
+}
+
+func (c *temperatureThresholdCondition) describe() string {
+	if c.rising {
+		return fmt.Sprintf("%s reached %g °C", c.systemName, c.threshold)
+	}
+	return fmt.Sprintf("%s fell to %g °C", c.systemName, c.threshold)
+}
+
+// steadyStateCondition triggers when every system's temperature changes slower than maxRate
+type steadyStateCondition struct {
+	maxRate float64 // K/h
+}
+
+func (c steadyStateCondition) check(state simulationState) bool {
+	for i, sys := range state.systems {
+		rate := (sys.getTemp() - state.previousTemps[i]) / state.timeStep * 60 * 60
+		if math.Abs(rate) >= c.maxRate {
+			return false
+		}
+	}
+	return true
+}
+
+func (c steadyStateCondition) describe() string {
+	return fmt.Sprintf("steady state: all temperatures changing slower than %g K/h", c.maxRate)
+}
+
+// wallClockCondition triggers when the run has taken longer than the budget in real time
+type wallClockCondition struct {
+	budget time.Duration
+}
+
+func (c wallClockCondition) check(state simulationState) bool {
+	return state.wallClockElapsed > c.budget
+}
+
+func (c wallClockCondition) describe() string {
+	return fmt.Sprintf("wall-clock budget of %s exceeded", c.budget)
+}
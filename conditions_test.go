@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestTemperatureThresholdCondition(t *testing.T) {
+	fs := &fluidSystem{name: "Tank", temperature: 45.5}
+	condition := &temperatureThresholdCondition{systemName: "Tank", threshold: 45.0}
+
+	state := simulationState{systems: []ISystem{fs}, timeStep: 1.0, previousTemps: []float64{44.5}}
+	if !condition.check(state) {
+		t.Error("expected rising crossing to trigger")
+	}
+	if desc := condition.describe(); desc != "Tank reached 45 °C" {
+		t.Errorf("unexpected description %q", desc)
+	}
+
+	state.previousTemps[0] = 46.0
+	if condition.check(state) {
+		t.Error("expected no trigger without a crossing")
+	}
+
+	fs.temperature = 44.0
+	if !condition.check(state) {
+		t.Error("expected falling crossing to trigger")
+	}
+	if desc := condition.describe(); desc != "Tank fell to 45 °C" {
+		t.Errorf("unexpected description %q", desc)
+	}
+}
+
+func TestSteadyStateCondition(t *testing.T) {
+	fs := &fluidSystem{name: "Tank", temperature: 20.001}
+	condition := steadyStateCondition{maxRate: 10.0}
+
+	state := simulationState{systems: []ISystem{fs}, timeStep: 1.0, previousTemps: []float64{20.0}}
+	if !condition.check(state) {
+		t.Error("expected 3.6 K/h to be below the threshold")
+	}
+	fs.temperature = 20.01
+	if condition.check(state) {
+		t.Error("expected 36 K/h to be above the threshold")
+	}
+}
+
+func TestWallClockCondition(t *testing.T) {
+	condition := wallClockCondition{budget: time.Second}
+	if condition.check(simulationState{wallClockElapsed: 500 * time.Millisecond}) {
+		t.Error("expected no trigger within the budget")
+	}
+	if !condition.check(simulationState{wallClockElapsed: 2 * time.Second}) {
+		t.Error("expected trigger after the budget")
+	}
+}
+
+func TestSimulation_StopCondition(t *testing.T) {
+	fs := &fluidSystem{
+		name:        "System",
+		fluidMass:   1.0,
+		temperature: 20.0,
+		heatInComponents: []IComponent{
+			mockHeatComponent{
+				mockComponent: mockComponent{name: "Heat In"},
+				heat:          specificHeatWater, // 1 K/s
+			},
+		},
+	}
+	sim := simulation{
+		systems:        []ISystem{fs},
+		timeStep:       1.0,
+		duration:       100.0,
+		stopConditions: []ICondition{&temperatureThresholdCondition{systemName: "System", threshold: 30.0}},
+		events:         []ICondition{&temperatureThresholdCondition{systemName: "System", threshold: 25.0}},
+	}
+
+	result := sim.run(context.Background())
+	if result.stopReason != "System reached 30 °C" {
+		t.Errorf("unexpected stop reason %q", result.stopReason)
+	}
+	// the system heats at 1 K/s, so it reaches each threshold at the end of a step
+	if result.stopTime != 10.0 {
+		t.Errorf("expected stop at 10s, got %v", result.stopTime)
+	}
+	if len(result.events) != 1 || result.events[0].time != 5.0 {
+		t.Errorf("expected one event at 5s, got %v", result.events)
+	}
+	// the series start at 0 s, and its temperatures line up with the event and stop times
+	if len(result.timeSeries) != 11 || result.timeSeries[5] != 5.0 || result.systemsSeries["System"][5].Value != 25.0 {
+		t.Errorf("expected 10 recorded steps from 0 s, got %v", result.timeSeries)
+	}
+}
@@ -4,56 +4,502 @@ import (
 	"errors"
 	"os"
 	"strconv"
+	"strings"
+	"time"
 )
 
 // Default values. These can be overriden with environment variables
 const (
-	outdoorAmbientTemp = 15.0   // Celsius
-	indoorAmbientTemp  = 22.0   // Celsius
-	outdoorHTC         = 15.0   // W/m^2*K
-	indoorHTC          = 5.0    // W/m^2*K
-	panelTemp          = 30.0   // Celsius
-	tankTemp           = 20.0   // Celsius
-	panelFluidMass     = 10.0   // kg
-	tankFluidMass      = 250.0  // kg
-	solarIrradiance    = 1000.0 // W/m^2
-	pumpFlowRate       = 0.2    // kg/s
-	panelSize          = 2.0    // m^2
-	panelEfficiency    = 0.6
-	durationHours      = 1.0 // hr
+	outdoorAmbientTemp        = 15.0 // Celsius
+	indoorAmbientTemp         = 22.0 // Celsius
+	outdoorHTC                = 15.0 // W/m^2*K
+	indoorHTC                 = 5.0  // W/m^2*K
+	panelTemp                 = 30.0 // Celsius
+	tankTemp                  = 20.0 // Celsius
+	panelFluidMass            = 10.0 // kg
+	panelFluid                = waterFluidName
+	panelDryMass              = 0.0   // kg; absorber plate, glazing and frame
+	panelDryCp                = 700.0 // J/(kg*K)
+	absorberNode              = false
+	absorberFluidHTC          = 300.0 // W/m^2*K
+	tankFluid                 = waterFluidName
+	tankFluidMass             = 250.0 // kg; 0 fills the tank's volume
+	tankSize                  = ""    // preset volume in L: 150, 300 or 500; replaces the height and diameter
+	tankHeight                = 1.7   // m; its length when horizontal
+	tankDiameter              = 0.6   // m
+	tankOrientation           = verticalTankOrientation
+	tankInsulationThickness   = 0.0  // m
+	tankInsulationK           = 0.04 // W/(m*K)
+	tankTopLoss               = true
+	tankSideLoss              = true
+	tankBottomLoss            = false  // the bottom stands on an insulated base
+	solarIrradiance           = 1000.0 // W/m^2
+	pumpFlowRate              = 0.2    // kg/s
+	heatExchanger             = ""     // empty couples the panel and tank directly
+	heatExchangerUA           = 0.0    // W/K; 0 derives the UA from the geometry
+	coilLength                = 12.0   // m
+	coilDiameter              = 0.022  // m
+	coilOuterHTC              = 400.0  // W/m^2*K; tank side of the coil
+	plateCount                = 20
+	plateArea                 = 0.05   // m^2
+	plateHTC                  = 2500.0 // W/m^2*K
+	tankLoopFlowRate          = 0.2    // kg/s; tank side of a plate heat exchanger
+	pumpModel                 = fixedPumpModel
+	pumpShutoffHead           = 6.0  // m; curve model only
+	pumpMaxFlow               = 2.5  // m^3/h; curve model only
+	pumpEfficiency            = 0.25 // wire to water
+	pumpSignalType            = pwmPumpSignal
+	pumpSignal                = 100.0 // % duty cycle or V
+	pumpControl               = constantPumpControl
+	pumpOnDeltaT              = 6.0              // K
+	pumpOffDeltaT             = 2.0              // K
+	drainBack                 = false            // the collectors drain into a reservoir when the pump stops
+	drainBackFillTime         = 120.0            // s
+	drainBackLift             = 5.0              // m; from the reservoir to the top of the collectors
+	reservoirFluidMass        = 10.0             // kg; left in the reservoir while the collectors are full
+	reservoirSurfaceArea      = 0.5              // m^2
+	reservoirHTC              = 1.0              // W/(m^2*K); through its insulation
+	pipeLength                = 20.0             // m; supply and return
+	pipeDiameter              = 0.016            // m
+	pipeRoughness             = 1.5e-6           // m
+	pipeFittingsK             = 10.0             // sum of minor loss coefficients
+	collectorPressureDrop     = 3.0              // kPa at PUMP_FLOW_RATE
+	heatExchangerPressureDrop = 10.0             // kPa at PUMP_FLOW_RATE
+	arrayBranches             = 1                // parallel branches
+	arraySeries               = 1                // panels in series in each branch
+	arrayReturn               = directReturn     // direct or reverse
+	arrayHeaderLength         = 1.2              // m; header length between neighbouring branches
+	arrayHeaderDiameter       = 0.022            // m
+	tankLayout                = singleTankLayout // single, cascade or diverter
+	preheatTankFluidMass      = 250.0            // kg; 0 fills the tank's volume
+	preheatTankTemp           = 15.0
+	diverterDeltaT            = 4.0 // K; how much hotter the collector must be to send flow to a tank
+	checkValvePressure        = 0.0 // kPa; cracking pressure, 0 for no check valve
+	drawFlowRate              = 0.0 // kg/s
+	drawSchedule              = ""  // CSV of hour,kg/s; replaces DRAW_FLOW_RATE
+	mainsTemp                 = 12.0
+	mixingValveSetpoint       = 0.0 // Celsius; 0 for no mixing valve
+	heatPumpEnabled           = false
+	heatPumpCapacity          = 1500.0               // W; rated heating capacity
+	heatPumpSource            = indoorHeatPumpSource // indoor or outdoor
+	heatPumpSetpoint          = 55.0                 // Celsius
+	heatPumpDeadband          = 5.0                  // K
+	heatPumpMaxTankTemp       = 65.0                 // Celsius
+	heatPumpMinSourceTemp     = -7.0                 // Celsius
+	heatPumpCOPMap            = ""                   // CSV; replaces the built-in COP map
+	heatPumpCapacityMap       = ""                   // CSV of capacity fractions; replaces the built-in capacity map
+	indoorZone                = false
+	zoneUA                    = 50.0   // W/K; envelope conductance to the outdoors
+	zoneGains                 = 200.0  // W; occupants, lighting and appliances
+	zoneVolume                = 30.0   // m^3; air volume
+	zoneThermalMass           = 500.0  // kJ/K; furnishings and interior surfaces
+	spaceHeating              = ""     // radiant-floor or radiators; empty for no space heating
+	heatingDesignLoad         = 5000.0 // W; building heat loss at HEATING_DESIGN_OUTDOOR_TEMP
+	heatingDesignOutdoorTemp  = -10.0  // Celsius
+	heatingIndoorTemp         = 20.0   // Celsius
+	heatingSupplyTemp         = 0.0    // Celsius; at the design outdoor temperature, 0 for the emitter default
+	heatingMinSupplyTemp      = 25.0   // Celsius
+	heatingMaxSupplyTemp      = 0.0    // Celsius; 0 for the emitter default
+	groundMeanTemp            = 10.0   // Celsius; annual mean surface temperature
+	groundAnnualAmplitude     = 10.0   // K
+	groundDailyAmplitude      = 5.0    // K
+	groundDiffusivity         = 0.05   // m^2/day
+	groundConductivity        = 1.5    // W/(m*K)
+	groundColdestDay          = 35.0   // day of the year the surface is coldest
+	tankBurialDepth           = 0.0    // m; 0 for tanks indoors
+	buriedTankHTC             = 0.5    // W/(m^2*K); through the insulation to the soil
+	soilRingRadius            = 0.0    // m; 0 for no soil ring
+	soilRingNodes             = 5
+	buriedPipeLength          = 0.0     // m; of the collector supply line
+	pipeBurialDepth           = 0.8     // m
+	pipeInsulationThickness   = 0.02    // m
+	pipeInsulationK           = 0.04    // W/(m*K)
+	seasonalStorage           = ""      // pit; empty for the panel and tank system
+	seasonalYears             = 3.0     // years; replaces DURATION_HOURS for seasonal storage
+	pitVolume                 = 60000.0 // m^3
+	pitDepth                  = 16.0    // m
+	pitSideSlope              = 2.0     // m of horizontal run per m of depth
+	pitLayers                 = 10
+	pitTemp                   = 10.0    // Celsius; at the start
+	pitLidU                   = 0.25    // W/(m^2*K); the floating insulated lid
+	pitWallU                  = 0.3     // W/(m^2*K); through the liner to the soil
+	pitMaxTemp                = 90.0    // Celsius; the collector field stops when the top of the pit reaches it
+	fieldArea                 = 20000.0 // m^2 of collectors charging the pit
+	fieldEfficiency           = 0.8     // η₀
+	fieldA1                   = 2.5     // W/(m^2*K)
+	fieldA2                   = 0.01    // W/(m^2*K^2)
+	fieldFlowRate             = 0.005   // kg/(s*m^2)
+	panelSize                 = 2.0     // m^2
+	panelEfficiency           = 0.6
+	collectorType             = flatPlateCollector // flat-plate, pvt or evacuated-tube
+	pvEfficiency              = 0.18               // electrical efficiency at PV_REFERENCE_TEMP
+	pvTempCoefficient         = 0.4                // %/K; efficiency lost per degree above PV_REFERENCE_TEMP
+	pvReferenceTemp           = 25.0               // Celsius
+	etcLossA1                 = 1.5                // W/(m^2*K)
+	etcLossA2                 = 0.005              // W/(m^2*K^2)
+	etcHeatCapacity           = 20.0               // kJ/(m^2*K); tubes and manifold
+	etcIAMFile                = ""                 // CSV of angle,longitudinal,transverse; replaces the built-in modifiers
+	panelEmissivity           = 0.0                // 0 disables radiation loss
+	panelTilt                 = 30.0               // degrees from horizontal
+	panelAzimuth              = 0.0                // degrees from south, positive towards west
+	latitude                  = 45.0               // degrees, positive north
+	startDay                  = 172.0              // day of the year the run starts on
+	startHour                 = 0.0                // solar time the run starts at
+	beamIrradiance            = 0.0                // W/m^2; direct normal, replaces SOLAR_IRRADIANCE with DIFFUSE_IRRADIANCE
+	diffuseIrradiance         = 0.0                // W/m^2; diffuse horizontal
+	horizonFile               = ""                 // CSV of azimuth,elevation
+	precipitation             = 0.0                // mm/h of water
+	precipitationSchedule     = ""                 // CSV of hour,mm/h; replaces PRECIPITATION
+	snowDepth                 = 0.0                // mm of water; snow on the panel at the start
+	soilingRate               = 0.0                // %/day; soiling loss gained without rain
+	maxSoiling                = 10.0               // %
+	cleaningRain              = 1.0                // mm/h; rain that washes the panel
+	skyModel                  = berdahlMartinSkyModel
+	dewPoint                  = 10.0 // Celsius
+	cloudCover                = 0.0  // 0 (clear) to 1 (overcast)
+	convectionModel           = constantConvectionModel
+	windSpeed                 = 3.0 // m/s
+	windSchedule              = ""  // CSV file of hour,wind speed; overrides windSpeed
+	durationHours             = 1.0 // hr
+	timeStep                  = 0.0 // s; 0 for 1 s, or an hour for seasonal storage
+	steadyState               = false
+	stopSteadyRate            = 0.0 // K/h; 0 disables the steady state stop condition
+	stopWallClock             = 0.0 // s; 0 disables the wall-clock stop condition
+	checkpointHours           = 0.0 // hr; 0 disables checkpoints
+	checkpointFile            = "checkpoint.json"
+	resumeFile                = "" // empty starts a new run
 )
 
 type config struct {
-	outdoorAmbientTemp float64
-	indoorAmbientTemp  float64
-	outdoorHTC         float64
-	indoorHTC          float64
-	panelTemp          float64
-	tankTemp           float64
-	panelFluidMass     float64
-	tankFluidMass      float64
-	solarIrradiance    float64
-	pumpFlowRate       float64
-	panelSize          float64
-	panelEfficiency    float64
-	durationHours      float64
+	outdoorAmbientTemp        float64
+	indoorAmbientTemp         float64
+	outdoorHTC                float64
+	indoorHTC                 float64
+	panelTemp                 float64
+	tankTemp                  float64
+	panelFluidMass            float64
+	panelFluid                string
+	panelDryMass              float64
+	panelDryCp                float64
+	absorberNode              bool
+	absorberFluidHTC          float64
+	tankFluid                 string
+	tankFluidMass             float64
+	tankSize                  string
+	tankHeight                float64
+	tankDiameter              float64
+	tankOrientation           string
+	tankInsulationThickness   float64
+	tankInsulationK           float64
+	tankTopLoss               bool
+	tankSideLoss              bool
+	tankBottomLoss            bool
+	solarIrradiance           float64
+	pumpFlowRate              float64
+	heatExchanger             string
+	heatExchangerUA           float64
+	coilLength                float64
+	coilDiameter              float64
+	coilOuterHTC              float64
+	plateCount                int
+	plateArea                 float64
+	plateHTC                  float64
+	tankLoopFlowRate          float64
+	pumpModel                 string
+	pumpShutoffHead           float64
+	pumpMaxFlow               float64
+	pumpEfficiency            float64
+	pumpSignalType            string
+	pumpSignal                float64
+	pumpControl               string
+	pumpOnDeltaT              float64
+	pumpOffDeltaT             float64
+	drainBack                 bool
+	drainBackFillTime         float64
+	drainBackLift             float64
+	reservoirFluidMass        float64
+	reservoirSurfaceArea      float64
+	reservoirHTC              float64
+	pipeLength                float64
+	pipeDiameter              float64
+	pipeRoughness             float64
+	pipeFittingsK             float64
+	collectorPressureDrop     float64
+	heatExchangerPressureDrop float64
+	arrayBranches             int
+	arraySeries               int
+	arrayReturn               string
+	arrayHeaderLength         float64
+	arrayHeaderDiameter       float64
+	tankLayout                string
+	preheatTankFluidMass      float64
+	preheatTankTemp           float64
+	diverterDeltaT            float64
+	checkValvePressure        float64
+	drawFlowRate              float64
+	drawSchedule              string
+	mainsTemp                 float64
+	mixingValveSetpoint       float64
+	heatPumpEnabled           bool
+	heatPumpCapacity          float64
+	heatPumpSource            string
+	heatPumpSetpoint          float64
+	heatPumpDeadband          float64
+	heatPumpMaxTankTemp       float64
+	heatPumpMinSourceTemp     float64
+	heatPumpCOPMap            string
+	heatPumpCapacityMap       string
+	indoorZone                bool
+	zoneUA                    float64
+	zoneGains                 float64
+	zoneVolume                float64
+	zoneThermalMass           float64
+	spaceHeating              string
+	heatingDesignLoad         float64
+	heatingDesignOutdoorTemp  float64
+	heatingIndoorTemp         float64
+	heatingSupplyTemp         float64
+	heatingMinSupplyTemp      float64
+	heatingMaxSupplyTemp      float64
+	groundMeanTemp            float64
+	groundAnnualAmplitude     float64
+	groundDailyAmplitude      float64
+	groundDiffusivity         float64
+	groundConductivity        float64
+	groundColdestDay          float64
+	tankBurialDepth           float64
+	buriedTankHTC             float64
+	soilRingRadius            float64
+	soilRingNodes             int
+	buriedPipeLength          float64
+	pipeBurialDepth           float64
+	pipeInsulationThickness   float64
+	pipeInsulationK           float64
+	seasonalStorage           string
+	seasonalYears             float64
+	pitVolume                 float64
+	pitDepth                  float64
+	pitSideSlope              float64
+	pitLayers                 int
+	pitTemp                   float64
+	pitLidU                   float64
+	pitWallU                  float64
+	pitMaxTemp                float64
+	fieldArea                 float64
+	fieldEfficiency           float64
+	fieldA1                   float64
+	fieldA2                   float64
+	fieldFlowRate             float64
+	panelSize                 float64
+	panelEfficiency           float64
+	collectorType             string
+	pvEfficiency              float64
+	pvTempCoefficient         float64
+	pvReferenceTemp           float64
+	etcLossA1                 float64
+	etcLossA2                 float64
+	etcHeatCapacity           float64
+	etcIAMFile                string
+	panelEmissivity           float64
+	panelTilt                 float64
+	panelAzimuth              float64
+	latitude                  float64
+	startDay                  float64
+	startHour                 float64
+	beamIrradiance            float64
+	diffuseIrradiance         float64
+	horizonFile               string
+	obstructions              []obstruction
+	precipitation             float64
+	precipitationSchedule     string
+	snowDepth                 float64
+	soilingRate               float64
+	maxSoiling                float64
+	cleaningRain              float64
+	skyModel                  string
+	dewPoint                  float64
+	cloudCover                float64
+	convectionModel           string
+	windSpeed                 float64
+	windSchedule              string
+	durationHours             float64
+	timeStep                  float64
+	steadyState               bool
+	stopTemps                 []systemThreshold
+	stopSteadyRate            float64
+	stopWallClock             time.Duration
+	eventTemps                []systemThreshold
+	checkpointHours           float64
+	checkpointFile            string
+	resumeFile                string
+}
+
+// systemThreshold is a temperature threshold for a named system, parsed from "SystemName:temp"
+type systemThreshold struct {
+	systemName string
+	temp       float64
 }
 
 func initializeConfig() config {
 	config := config{
-		outdoorAmbientTemp: outdoorAmbientTemp,
-		indoorAmbientTemp:  indoorAmbientTemp,
-		outdoorHTC:         outdoorHTC,
-		indoorHTC:          indoorHTC,
-		panelTemp:          panelTemp,
-		tankTemp:           tankTemp,
-		panelFluidMass:     panelFluidMass,
-		tankFluidMass:      tankFluidMass,
-		solarIrradiance:    solarIrradiance,
-		pumpFlowRate:       pumpFlowRate,
-		panelSize:          panelSize,
-		panelEfficiency:    panelEfficiency,
-		durationHours:      durationHours,
+		outdoorAmbientTemp:        outdoorAmbientTemp,
+		indoorAmbientTemp:         indoorAmbientTemp,
+		outdoorHTC:                outdoorHTC,
+		indoorHTC:                 indoorHTC,
+		panelTemp:                 panelTemp,
+		tankTemp:                  tankTemp,
+		panelFluidMass:            panelFluidMass,
+		panelFluid:                panelFluid,
+		panelDryMass:              panelDryMass,
+		panelDryCp:                panelDryCp,
+		absorberNode:              absorberNode,
+		absorberFluidHTC:          absorberFluidHTC,
+		tankFluid:                 tankFluid,
+		tankFluidMass:             tankFluidMass,
+		tankSize:                  tankSize,
+		tankHeight:                tankHeight,
+		tankDiameter:              tankDiameter,
+		tankOrientation:           tankOrientation,
+		tankInsulationThickness:   tankInsulationThickness,
+		tankInsulationK:           tankInsulationK,
+		tankTopLoss:               tankTopLoss,
+		tankSideLoss:              tankSideLoss,
+		tankBottomLoss:            tankBottomLoss,
+		solarIrradiance:           solarIrradiance,
+		pumpFlowRate:              pumpFlowRate,
+		heatExchanger:             heatExchanger,
+		heatExchangerUA:           heatExchangerUA,
+		coilLength:                coilLength,
+		coilDiameter:              coilDiameter,
+		coilOuterHTC:              coilOuterHTC,
+		plateCount:                plateCount,
+		plateArea:                 plateArea,
+		plateHTC:                  plateHTC,
+		tankLoopFlowRate:          tankLoopFlowRate,
+		pumpModel:                 pumpModel,
+		pumpShutoffHead:           pumpShutoffHead,
+		pumpMaxFlow:               pumpMaxFlow,
+		pumpEfficiency:            pumpEfficiency,
+		pumpSignalType:            pumpSignalType,
+		pumpSignal:                pumpSignal,
+		pumpControl:               pumpControl,
+		pumpOnDeltaT:              pumpOnDeltaT,
+		pumpOffDeltaT:             pumpOffDeltaT,
+		drainBack:                 drainBack,
+		drainBackFillTime:         drainBackFillTime,
+		drainBackLift:             drainBackLift,
+		reservoirFluidMass:        reservoirFluidMass,
+		reservoirSurfaceArea:      reservoirSurfaceArea,
+		reservoirHTC:              reservoirHTC,
+		pipeLength:                pipeLength,
+		pipeDiameter:              pipeDiameter,
+		pipeRoughness:             pipeRoughness,
+		pipeFittingsK:             pipeFittingsK,
+		collectorPressureDrop:     collectorPressureDrop,
+		heatExchangerPressureDrop: heatExchangerPressureDrop,
+		arrayBranches:             arrayBranches,
+		arraySeries:               arraySeries,
+		arrayReturn:               arrayReturn,
+		arrayHeaderLength:         arrayHeaderLength,
+		arrayHeaderDiameter:       arrayHeaderDiameter,
+		tankLayout:                tankLayout,
+		preheatTankFluidMass:      preheatTankFluidMass,
+		preheatTankTemp:           preheatTankTemp,
+		diverterDeltaT:            diverterDeltaT,
+		checkValvePressure:        checkValvePressure,
+		drawFlowRate:              drawFlowRate,
+		drawSchedule:              drawSchedule,
+		mainsTemp:                 mainsTemp,
+		mixingValveSetpoint:       mixingValveSetpoint,
+		heatPumpEnabled:           heatPumpEnabled,
+		heatPumpCapacity:          heatPumpCapacity,
+		heatPumpSource:            heatPumpSource,
+		heatPumpSetpoint:          heatPumpSetpoint,
+		heatPumpDeadband:          heatPumpDeadband,
+		heatPumpMaxTankTemp:       heatPumpMaxTankTemp,
+		heatPumpMinSourceTemp:     heatPumpMinSourceTemp,
+		heatPumpCOPMap:            heatPumpCOPMap,
+		heatPumpCapacityMap:       heatPumpCapacityMap,
+		indoorZone:                indoorZone,
+		zoneUA:                    zoneUA,
+		zoneGains:                 zoneGains,
+		zoneVolume:                zoneVolume,
+		zoneThermalMass:           zoneThermalMass,
+		spaceHeating:              spaceHeating,
+		heatingDesignLoad:         heatingDesignLoad,
+		heatingDesignOutdoorTemp:  heatingDesignOutdoorTemp,
+		heatingIndoorTemp:         heatingIndoorTemp,
+		heatingSupplyTemp:         heatingSupplyTemp,
+		heatingMinSupplyTemp:      heatingMinSupplyTemp,
+		heatingMaxSupplyTemp:      heatingMaxSupplyTemp,
+		groundMeanTemp:            groundMeanTemp,
+		groundAnnualAmplitude:     groundAnnualAmplitude,
+		groundDailyAmplitude:      groundDailyAmplitude,
+		groundDiffusivity:         groundDiffusivity,
+		groundConductivity:        groundConductivity,
+		groundColdestDay:          groundColdestDay,
+		tankBurialDepth:           tankBurialDepth,
+		buriedTankHTC:             buriedTankHTC,
+		soilRingRadius:            soilRingRadius,
+		soilRingNodes:             soilRingNodes,
+		buriedPipeLength:          buriedPipeLength,
+		pipeBurialDepth:           pipeBurialDepth,
+		pipeInsulationThickness:   pipeInsulationThickness,
+		pipeInsulationK:           pipeInsulationK,
+		seasonalStorage:           seasonalStorage,
+		seasonalYears:             seasonalYears,
+		pitVolume:                 pitVolume,
+		pitDepth:                  pitDepth,
+		pitSideSlope:              pitSideSlope,
+		pitLayers:                 pitLayers,
+		pitTemp:                   pitTemp,
+		pitLidU:                   pitLidU,
+		pitWallU:                  pitWallU,
+		pitMaxTemp:                pitMaxTemp,
+		fieldArea:                 fieldArea,
+		fieldEfficiency:           fieldEfficiency,
+		fieldA1:                   fieldA1,
+		fieldA2:                   fieldA2,
+		fieldFlowRate:             fieldFlowRate,
+		panelSize:                 panelSize,
+		panelEfficiency:           panelEfficiency,
+		collectorType:             collectorType,
+		pvEfficiency:              pvEfficiency,
+		pvTempCoefficient:         pvTempCoefficient,
+		pvReferenceTemp:           pvReferenceTemp,
+		etcLossA1:                 etcLossA1,
+		etcLossA2:                 etcLossA2,
+		etcHeatCapacity:           etcHeatCapacity,
+		etcIAMFile:                etcIAMFile,
+		panelEmissivity:           panelEmissivity,
+		panelTilt:                 panelTilt,
+		panelAzimuth:              panelAzimuth,
+		latitude:                  latitude,
+		startDay:                  startDay,
+		startHour:                 startHour,
+		beamIrradiance:            beamIrradiance,
+		diffuseIrradiance:         diffuseIrradiance,
+		horizonFile:               horizonFile,
+		precipitation:             precipitation,
+		precipitationSchedule:     precipitationSchedule,
+		snowDepth:                 snowDepth,
+		soilingRate:               soilingRate,
+		maxSoiling:                maxSoiling,
+		cleaningRain:              cleaningRain,
+		skyModel:                  skyModel,
+		dewPoint:                  dewPoint,
+		cloudCover:                cloudCover,
+		convectionModel:           convectionModel,
+		windSpeed:                 windSpeed,
+		windSchedule:              windSchedule,
+		durationHours:             durationHours,
+		timeStep:                  timeStep,
+		steadyState:               steadyState,
+		stopSteadyRate:            stopSteadyRate,
+		stopWallClock:             time.Duration(stopWallClock * float64(time.Second)),
+		checkpointHours:           checkpointHours,
+		checkpointFile:            checkpointFile,
+		resumeFile:                resumeFile,
 	}
 
 	var err error
@@ -70,7 +516,7 @@ func initializeConfig() config {
 		handleParseEnvError(err)
 	}
 	if val := os.Getenv("INDOOR_HTC"); val != "" {
-		config.indoorAmbientTemp, err = strconv.ParseFloat(val, 64)
+		config.indoorHTC, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
 	if val := os.Getenv("PANEL_TEMP"); val != "" {
@@ -85,10 +531,72 @@ func initializeConfig() config {
 		config.panelFluidMass, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
+	if val := os.Getenv("PANEL_FLUID"); val != "" {
+		config.panelFluid = val
+	}
+	if val := os.Getenv("PANEL_DRY_MASS"); val != "" {
+		config.panelDryMass, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PANEL_DRY_SPECIFIC_HEAT"); val != "" {
+		config.panelDryCp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PANEL_ABSORBER_NODE"); val != "" {
+		config.absorberNode, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ABSORBER_FLUID_HTC"); val != "" {
+		config.absorberFluidHTC, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_FLUID"); val != "" {
+		config.tankFluid = val
+	}
 	if val := os.Getenv("TANK_WATER_MASS"); val != "" {
 		config.tankFluidMass, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
+	if val := os.Getenv("TANK_SIZE"); val != "" {
+		if _, ok := tankSizes[val]; !ok {
+			panic(errors.New("TANK_SIZE must be 150, 300 or 500"))
+		}
+		config.tankSize = val
+	}
+	if val := os.Getenv("TANK_HEIGHT"); val != "" {
+		config.tankHeight, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_DIAMETER"); val != "" {
+		config.tankDiameter, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_ORIENTATION"); val != "" {
+		if val != verticalTankOrientation && val != horizontalTankOrientation {
+			panic(errors.New("TANK_ORIENTATION must be vertical or horizontal"))
+		}
+		config.tankOrientation = val
+	}
+	if val := os.Getenv("TANK_INSULATION_THICKNESS"); val != "" {
+		config.tankInsulationThickness, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_INSULATION_CONDUCTIVITY"); val != "" {
+		config.tankInsulationK, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_TOP_LOSS"); val != "" {
+		config.tankTopLoss, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_SIDE_LOSS"); val != "" {
+		config.tankSideLoss, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_BOTTOM_LOSS"); val != "" {
+		config.tankBottomLoss, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
 	if val := os.Getenv("SOLAR_IRRADIANCE"); val != "" {
 		config.solarIrradiance, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
@@ -97,6 +605,397 @@ func initializeConfig() config {
 		config.pumpFlowRate, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
+	if val := os.Getenv("HEAT_EXCHANGER"); val != "" {
+		if val != immersedCoilHeatExchanger && val != plateHeatExchanger {
+			panic(errors.New("HEAT_EXCHANGER must be coil or plate"))
+		}
+		config.heatExchanger = val
+	}
+	if val := os.Getenv("HEAT_EXCHANGER_UA"); val != "" {
+		config.heatExchangerUA, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COIL_LENGTH"); val != "" {
+		config.coilLength, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COIL_DIAMETER"); val != "" {
+		config.coilDiameter, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COIL_OUTER_HTC"); val != "" {
+		config.coilOuterHTC, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PLATE_COUNT"); val != "" {
+		config.plateCount, err = strconv.Atoi(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PLATE_AREA"); val != "" {
+		config.plateArea, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PLATE_HTC"); val != "" {
+		config.plateHTC, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_LOOP_FLOW_RATE"); val != "" {
+		config.tankLoopFlowRate, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_MODEL"); val != "" {
+		if val != fixedPumpModel && val != curvePumpModel {
+			panic(errors.New("PUMP_MODEL must be fixed or curve"))
+		}
+		config.pumpModel = val
+	}
+	if val := os.Getenv("PUMP_SHUTOFF_HEAD"); val != "" {
+		config.pumpShutoffHead, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_MAX_FLOW"); val != "" {
+		config.pumpMaxFlow, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_EFFICIENCY"); val != "" {
+		config.pumpEfficiency, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_SIGNAL_TYPE"); val != "" {
+		if val != pwmPumpSignal && val != voltagePumpSignal {
+			panic(errors.New("PUMP_SIGNAL_TYPE must be pwm or voltage"))
+		}
+		config.pumpSignalType = val
+	}
+	if val := os.Getenv("PUMP_SIGNAL"); val != "" {
+		config.pumpSignal, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_CONTROL"); val != "" {
+		if val != constantPumpControl && val != differentialPumpControl {
+			panic(errors.New("PUMP_CONTROL must be constant or differential"))
+		}
+		config.pumpControl = val
+	}
+	if val := os.Getenv("PUMP_ON_DELTA_T"); val != "" {
+		config.pumpOnDeltaT, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PUMP_OFF_DELTA_T"); val != "" {
+		config.pumpOffDeltaT, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK"); val != "" {
+		config.drainBack, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK_FILL_TIME"); val != "" {
+		config.drainBackFillTime, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK_LIFT"); val != "" {
+		config.drainBackLift, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK_RESERVOIR_MASS"); val != "" {
+		config.reservoirFluidMass, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK_RESERVOIR_AREA"); val != "" {
+		config.reservoirSurfaceArea, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAIN_BACK_RESERVOIR_HTC"); val != "" {
+		config.reservoirHTC, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_LENGTH"); val != "" {
+		config.pipeLength, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_DIAMETER"); val != "" {
+		config.pipeDiameter, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_ROUGHNESS"); val != "" {
+		config.pipeRoughness, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_FITTINGS_K"); val != "" {
+		config.pipeFittingsK, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_PRESSURE_DROP"); val != "" {
+		config.collectorPressureDrop, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_EXCHANGER_PRESSURE_DROP"); val != "" {
+		config.heatExchangerPressureDrop, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ARRAY_LAYOUT"); val != "" {
+		config.arrayBranches, config.arraySeries, err = parseArrayLayout(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ARRAY_RETURN"); val != "" {
+		if val != directReturn && val != reverseReturn {
+			panic(errors.New("ARRAY_RETURN must be direct or reverse"))
+		}
+		config.arrayReturn = val
+	}
+	if val := os.Getenv("ARRAY_HEADER_LENGTH"); val != "" {
+		config.arrayHeaderLength, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ARRAY_HEADER_DIAMETER"); val != "" {
+		config.arrayHeaderDiameter, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_LAYOUT"); val != "" {
+		if val != singleTankLayout && val != cascadeTankLayout && val != diverterTankLayout {
+			panic(errors.New("TANK_LAYOUT must be single, cascade or diverter"))
+		}
+		config.tankLayout = val
+	}
+	if val := os.Getenv("PREHEAT_TANK_WATER_MASS"); val != "" {
+		config.preheatTankFluidMass, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PREHEAT_TANK_TEMP"); val != "" {
+		config.preheatTankTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DIVERTER_DELTA_T"); val != "" {
+		config.diverterDeltaT, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CHECK_VALVE_PRESSURE"); val != "" {
+		config.checkValvePressure, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAW_FLOW_RATE"); val != "" {
+		config.drawFlowRate, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DRAW_SCHEDULE"); val != "" {
+		config.drawSchedule = val
+	}
+	if val := os.Getenv("MAINS_TEMP"); val != "" {
+		config.mainsTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("MIXING_VALVE_SETPOINT"); val != "" {
+		config.mixingValveSetpoint, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP"); val != "" {
+		config.heatPumpEnabled, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_CAPACITY"); val != "" {
+		config.heatPumpCapacity, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_SOURCE"); val != "" {
+		if val != indoorHeatPumpSource && val != outdoorHeatPumpSource {
+			panic(errors.New("HEAT_PUMP_SOURCE must be indoor or outdoor"))
+		}
+		config.heatPumpSource = val
+	}
+	if val := os.Getenv("HEAT_PUMP_SETPOINT"); val != "" {
+		config.heatPumpSetpoint, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_DEADBAND"); val != "" {
+		config.heatPumpDeadband, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_MAX_TANK_TEMP"); val != "" {
+		config.heatPumpMaxTankTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_MIN_SOURCE_TEMP"); val != "" {
+		config.heatPumpMinSourceTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEAT_PUMP_COP_MAP"); val != "" {
+		config.heatPumpCOPMap = val
+	}
+	if val := os.Getenv("HEAT_PUMP_CAPACITY_MAP"); val != "" {
+		config.heatPumpCapacityMap = val
+	}
+	if val := os.Getenv("INDOOR_ZONE"); val != "" {
+		config.indoorZone, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ZONE_UA"); val != "" {
+		config.zoneUA, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ZONE_GAINS"); val != "" {
+		config.zoneGains, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ZONE_VOLUME"); val != "" {
+		config.zoneVolume, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ZONE_THERMAL_MASS"); val != "" {
+		config.zoneThermalMass, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SPACE_HEATING"); val != "" {
+		if val != radiantFloorEmitter && val != radiatorEmitter {
+			panic(errors.New("SPACE_HEATING must be radiant-floor or radiators"))
+		}
+		config.spaceHeating = val
+	}
+	if val := os.Getenv("HEATING_DESIGN_LOAD"); val != "" {
+		config.heatingDesignLoad, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEATING_DESIGN_OUTDOOR_TEMP"); val != "" {
+		config.heatingDesignOutdoorTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEATING_INDOOR_TEMP"); val != "" {
+		config.heatingIndoorTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	// the demand and the reset curve scale with the design temperature difference
+	if config.heatingIndoorTemp <= config.heatingDesignOutdoorTemp {
+		panic(errors.New("HEATING_INDOOR_TEMP must be above HEATING_DESIGN_OUTDOOR_TEMP"))
+	}
+	if val := os.Getenv("HEATING_SUPPLY_TEMP"); val != "" {
+		config.heatingSupplyTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEATING_MIN_SUPPLY_TEMP"); val != "" {
+		config.heatingMinSupplyTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HEATING_MAX_SUPPLY_TEMP"); val != "" {
+		config.heatingMaxSupplyTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_MEAN_TEMP"); val != "" {
+		config.groundMeanTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_ANNUAL_AMPLITUDE"); val != "" {
+		config.groundAnnualAmplitude, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_DAILY_AMPLITUDE"); val != "" {
+		config.groundDailyAmplitude, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_DIFFUSIVITY"); val != "" {
+		config.groundDiffusivity, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_CONDUCTIVITY"); val != "" {
+		config.groundConductivity, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("GROUND_COLDEST_DAY"); val != "" {
+		config.groundColdestDay, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("TANK_BURIAL_DEPTH"); val != "" {
+		config.tankBurialDepth, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("BURIED_TANK_HTC"); val != "" {
+		config.buriedTankHTC, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SOIL_RING_RADIUS"); val != "" {
+		config.soilRingRadius, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SOIL_RING_NODES"); val != "" {
+		config.soilRingNodes, err = strconv.Atoi(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("BURIED_PIPE_LENGTH"); val != "" {
+		config.buriedPipeLength, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_BURIAL_DEPTH"); val != "" {
+		config.pipeBurialDepth, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_INSULATION_THICKNESS"); val != "" {
+		config.pipeInsulationThickness, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIPE_INSULATION_CONDUCTIVITY"); val != "" {
+		config.pipeInsulationK, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SEASONAL_STORAGE"); val != "" {
+		if val != pitSeasonalStorage {
+			panic(errors.New("SEASONAL_STORAGE must be pit"))
+		}
+		config.seasonalStorage = val
+	}
+	if val := os.Getenv("SEASONAL_YEARS"); val != "" {
+		config.seasonalYears, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_VOLUME"); val != "" {
+		config.pitVolume, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_DEPTH"); val != "" {
+		config.pitDepth, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_SIDE_SLOPE"); val != "" {
+		config.pitSideSlope, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_LAYERS"); val != "" {
+		config.pitLayers, err = strconv.Atoi(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_TEMP"); val != "" {
+		config.pitTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_LID_U"); val != "" {
+		config.pitLidU, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_WALL_U"); val != "" {
+		config.pitWallU, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PIT_MAX_TEMP"); val != "" {
+		config.pitMaxTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_FIELD_AREA"); val != "" {
+		config.fieldArea, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_FIELD_EFFICIENCY"); val != "" {
+		config.fieldEfficiency, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_FIELD_A1"); val != "" {
+		config.fieldA1, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_FIELD_A2"); val != "" {
+		config.fieldA2, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("COLLECTOR_FIELD_FLOW_RATE"); val != "" {
+		config.fieldFlowRate, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
 	if val := os.Getenv("PANEL_SIZE"); val != "" {
 		config.panelSize, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
@@ -105,15 +1004,233 @@ func initializeConfig() config {
 		config.panelEfficiency, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
+	if val := os.Getenv("COLLECTOR_TYPE"); val != "" {
+		if val != flatPlateCollector && val != pvtCollector && val != evacuatedTubeCollector {
+			panic(errors.New("COLLECTOR_TYPE must be flat-plate, pvt or evacuated-tube"))
+		}
+		config.collectorType = val
+	}
+	if val := os.Getenv("PV_EFFICIENCY"); val != "" {
+		config.pvEfficiency, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PV_TEMP_COEFFICIENT"); val != "" {
+		config.pvTempCoefficient, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PV_REFERENCE_TEMP"); val != "" {
+		config.pvReferenceTemp, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ETC_LOSS_A1"); val != "" {
+		config.etcLossA1, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ETC_LOSS_A2"); val != "" {
+		config.etcLossA2, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ETC_HEAT_CAPACITY"); val != "" {
+		config.etcHeatCapacity, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("ETC_IAM_FILE"); val != "" {
+		config.etcIAMFile = val
+	}
+	if val := os.Getenv("PANEL_EMISSIVITY"); val != "" {
+		config.panelEmissivity, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PANEL_TILT"); val != "" {
+		config.panelTilt, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PANEL_AZIMUTH"); val != "" {
+		config.panelAzimuth, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("LATITUDE"); val != "" {
+		config.latitude, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("START_DAY"); val != "" {
+		config.startDay, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("START_HOUR"); val != "" {
+		config.startHour, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("BEAM_IRRADIANCE"); val != "" {
+		config.beamIrradiance, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("DIFFUSE_IRRADIANCE"); val != "" {
+		config.diffuseIrradiance, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("HORIZON_FILE"); val != "" {
+		config.horizonFile = val
+	}
+	if val := os.Getenv("OBSTRUCTIONS"); val != "" {
+		config.obstructions, err = parseObstructions(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PRECIPITATION"); val != "" {
+		config.precipitation, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("PRECIPITATION_SCHEDULE"); val != "" {
+		config.precipitationSchedule = val
+	}
+	if val := os.Getenv("SNOW_DEPTH"); val != "" {
+		config.snowDepth, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SOILING_RATE"); val != "" {
+		config.soilingRate, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SOILING_MAX"); val != "" {
+		config.maxSoiling, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CLEANING_RAIN"); val != "" {
+		config.cleaningRain, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("SKY_MODEL"); val != "" {
+		if val != swinbankSkyModel && val != berdahlMartinSkyModel {
+			panic(errors.New("SKY_MODEL must be swinbank or berdahl-martin"))
+		}
+		config.skyModel = val
+	}
+	if val := os.Getenv("DEW_POINT"); val != "" {
+		config.dewPoint, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CLOUD_COVER"); val != "" {
+		config.cloudCover, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CONVECTION_MODEL"); val != "" {
+		config.convectionModel = val
+	}
+	if val := os.Getenv("WIND_SPEED"); val != "" {
+		config.windSpeed, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("WIND_SCHEDULE"); val != "" {
+		config.windSchedule = val
+	}
 	if val := os.Getenv("DURATION_HOURS"); val != "" {
 		config.durationHours, err = strconv.ParseFloat(val, 64)
 		handleParseEnvError(err)
 	}
+	if val := os.Getenv("TIME_STEP"); val != "" {
+		config.timeStep, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("STEADY_STATE"); val != "" {
+		config.steadyState, err = strconv.ParseBool(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("STOP_TEMPS"); val != "" {
+		config.stopTemps, err = parseSystemThresholds(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("STOP_STEADY_RATE"); val != "" {
+		config.stopSteadyRate, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("STOP_WALL_CLOCK"); val != "" {
+		var seconds float64
+		seconds, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+		config.stopWallClock = time.Duration(seconds * float64(time.Second))
+	}
+	if val := os.Getenv("EVENT_TEMPS"); val != "" {
+		config.eventTemps, err = parseSystemThresholds(val)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CHECKPOINT_HOURS"); val != "" {
+		config.checkpointHours, err = strconv.ParseFloat(val, 64)
+		handleParseEnvError(err)
+	}
+	if val := os.Getenv("CHECKPOINT_FILE"); val != "" {
+		config.checkpointFile = val
+	}
+	if val := os.Getenv("RESUME_FILE"); val != "" {
+		config.resumeFile = val
+	}
 	return config
 }
 
+// parseSystemThresholds parses a comma separated list of thresholds, e.g. "StorageTank:45,SolarPanel:90"
+func parseSystemThresholds(val string) ([]systemThreshold, error) {
+	thresholds := []systemThreshold{}
+	for _, entry := range strings.Split(val, ",") {
+		name, tempString, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		if !ok {
+			return nil, errors.New("threshold must have the form SystemName:temp")
+		}
+		temp, err := strconv.ParseFloat(tempString, 64)
+		if err != nil {
+			return nil, err
+		}
+		thresholds = append(thresholds, systemThreshold{systemName: name, temp: temp})
+	}
+	return thresholds, nil
+}
+
+// parseArrayLayout parses a layout of the form BRANCHESxSERIES, e.g. 3x4 is three parallel branches of four panels
+func parseArrayLayout(val string) (int, int, error) {
+	branchesString, seriesString, ok := strings.Cut(strings.ToLower(strings.TrimSpace(val)), "x")
+	if !ok {
+		return 0, 0, errors.New("layout must have the form BRANCHESxSERIES")
+	}
+	branches, err := strconv.Atoi(branchesString)
+	if err != nil {
+		return 0, 0, err
+	}
+	series, err := strconv.Atoi(seriesString)
+	if err != nil {
+		return 0, 0, err
+	}
+	if branches < 1 || series < 1 {
+		return 0, 0, errors.New("layout must have at least one branch and one panel in series")
+	}
+	return branches, series, nil
+}
+
+// parseObstructions parses comma separated obstructions of the form from:to:top or from:to:bottom:top,
+// with azimuths and elevations in degrees
+func parseObstructions(val string) ([]obstruction, error) {
+	obstructions := []obstruction{}
+	for _, entry := range strings.Split(val, ",") {
+		fields := strings.Split(strings.TrimSpace(entry), ":")
+		if len(fields) != 3 && len(fields) != 4 {
+			return nil, errors.New("obstruction must have the form from:to:top or from:to:bottom:top")
+		}
+		values := make([]float64, len(fields))
+		for i, field := range fields {
+			value, err := strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		o := obstruction{fromAzimuth: values[0], toAzimuth: values[1], top: values[len(values)-1]}
+		if len(values) == 4 {
+			o.bottom = values[2]
+		}
+		obstructions = append(obstructions, o)
+	}
+	return obstructions, nil
+}
+
 func handleParseEnvError(err error) {
 	if err != nil {
-		panic(errors.New("could not parse environment variable to float"))
+		panic(errors.New("could not parse environment variable"))
 	}
 }
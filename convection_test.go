@@ -0,0 +1,67 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestWindConvectionCorrelations(t *testing.T) {
+	tests := []struct {
+		name     string
+		htc      float64
+		expected float64
+	}{
+		{name: "McAdams", htc: mcAdamsHTC(2.0), expected: 13.3},
+		{name: "Watmuff", htc: watmuffHTC(2.0), expected: 8.8},
+		{name: "Test", htc: testHTC(2.0), expected: 13.67},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.htc-tt.expected) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.expected, tt.htc)
+			}
+		})
+	}
+}
+
+func TestNaturalConvectionHTC(t *testing.T) {
+	if htc := naturalConvectionHTC(20.0, 20.0, 30.0, 1.0); htc != 0.0 {
+		t.Errorf("expected no convection without a temperature difference, got %v", htc)
+	}
+
+	small := naturalConvectionHTC(30.0, 20.0, 45.0, 1.0)
+	large := naturalConvectionHTC(60.0, 20.0, 45.0, 1.0)
+	if small <= 0 || large <= small {
+		t.Errorf("expected HTC to grow with temperature difference, got %v and %v", small, large)
+	}
+	// natural convection from a collector is a few W/m^2*K
+	if large < 1.0 || large > 10.0 {
+		t.Errorf("expected a natural convection HTC of a few W/m^2*K, got %v", large)
+	}
+
+	if horizontal := naturalConvectionHTC(60.0, 20.0, 0.0, 1.0); horizontal <= 0 {
+		t.Errorf("expected positive HTC for a horizontal plate, got %v", horizontal)
+	}
+}
+
+func TestNewConvectionCorrelation(t *testing.T) {
+	wind := 0.0
+	correlation, err := newConvectionCorrelation(mcAdamsConvectionModel, func() float64 { return wind }, 30.0, 1.4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if htc := correlation(40.0, 20.0); htc != 5.7 {
+		t.Errorf("expected 5.7 without wind, got %v", htc)
+	}
+	wind = 5.0
+	if htc := correlation(40.0, 20.0); htc != 5.7+3.8*5.0 {
+		t.Errorf("expected HTC to follow the wind speed, got %v", htc)
+	}
+
+	if correlation, err := newConvectionCorrelation(constantConvectionModel, nil, 30.0, 1.4); err != nil || correlation != nil {
+		t.Errorf("expected no correlation for the constant model, got %v", err)
+	}
+	if _, err := newConvectionCorrelation("unknown", nil, 30.0, 1.4); err == nil {
+		t.Error("expected error for an unknown model")
+	}
+}
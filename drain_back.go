@@ -0,0 +1,126 @@
+// drain-back: the collectors empty into a reservoir whenever the pump stops, so they can't freeze or boil while idle.
+// When the pump starts, it first has to lift the fluid from the reservoir back up to the collectors, which takes
+// a fill duration and extra pump head, and the fluid only circulates once the collectors are full.
+package main
+
+const (
+	drainedPhase = iota
+	fillingPhase
+	filledPhase
+)
+
+type drainBackSystem struct {
+	name       string
+	panels     []*solarPanel
+	fluidMass  float64 // kg; in each full panel
+	reservoir  *fluidSystem
+	pump       *pump
+	fillTime   float64 // s; of pumping to fill the drained collectors
+	liftHead   float64 // m; from the reservoir to the top of the collectors
+	phase      int
+	fillTimer  float64 // s; pumped so far in the current fill
+	fillCount  int
+	fillEnergy float64 // J; pump electrical energy while filling
+	dataRecorder
+}
+
+func (d *drainBackSystem) getName() string {
+	return d.name
+}
+
+// initialize drains the collectors into the reservoir
+func (d *drainBackSystem) initialize() {
+	d.phase = drainedPhase
+	for _, sp := range d.panels {
+		moveFluid(&sp.fluidSystem, d.reservoir, sp.fluidMass)
+	}
+}
+
+// update follows the pump, which must be updated first: the collectors drain as soon as it stops,
+// and fill once it has pumped for the fill duration
+func (d *drainBackSystem) update(time float64, timeStep float64) {
+	running := d.pump.flowMass > 0
+	switch {
+	case !running && d.phase != drainedPhase:
+		d.phase = drainedPhase
+		for _, sp := range d.panels {
+			moveFluid(&sp.fluidSystem, d.reservoir, sp.fluidMass)
+		}
+	case running && d.phase == drainedPhase:
+		d.phase = fillingPhase
+		d.fillTimer = 0.0
+		d.fillCount++
+	}
+	if d.phase == fillingPhase {
+		d.fillTimer += timeStep
+		d.fillEnergy += d.pump.electricalPower * timeStep
+		if d.fillTimer >= d.fillTime-float64EqualityThreshold {
+			d.phase = filledPhase
+			for _, sp := range d.panels {
+				moveFluid(d.reservoir, &sp.fluidSystem, d.fluidMass)
+			}
+		}
+	}
+
+	collectorFluid := 0.0
+	for _, sp := range d.panels {
+		collectorFluid += sp.fluidMass
+	}
+	d.addDataPoint("Collector Fluid", collectorFluid)
+	d.addDataPoint("Reservoir Fluid", d.reservoir.fluidMass)
+}
+
+// getFlowMass is the pump's flow once it circulates through the full collectors
+func (d *drainBackSystem) getFlowMass() float64 {
+	if d.phase != filledPhase {
+		return 0.0
+	}
+	return d.pump.flowMass
+}
+
+// getStaticHead is the lift the pump works against until the collectors are full, after which the falling return
+// side balances the rising supply side
+func (d *drainBackSystem) getStaticHead() float64 {
+	if d.phase == filledPhase {
+		return 0.0
+	}
+	return d.liftHead
+}
+
+func (d *drainBackSystem) getState() map[string]float64 {
+	return map[string]float64{
+		"phase":      float64(d.phase),
+		"fillTimer":  d.fillTimer,
+		"fillCount":  float64(d.fillCount),
+		"fillEnergy": d.fillEnergy,
+	}
+}
+
+// setState restores where the fluid is; the reservoir and panels' temperatures are restored with the systems
+func (d *drainBackSystem) setState(state map[string]float64) error {
+	d.phase = int(state["phase"])
+	d.fillTimer = state["fillTimer"]
+	d.fillCount = int(state["fillCount"])
+	d.fillEnergy = state["fillEnergy"]
+	if d.phase == filledPhase {
+		for _, sp := range d.panels {
+			d.reservoir.fluidMass -= d.fluidMass - sp.fluidMass
+			sp.fluidMass = d.fluidMass
+		}
+	}
+	return nil
+}
+
+// moveFluid moves fluid from one system to another, where it mixes with the fluid already there.
+// The fluid carries its heat with it, so the system it leaves keeps its temperature.
+func moveFluid(from *fluidSystem, to *fluidSystem, mass float64) {
+	if mass <= 0 {
+		return
+	}
+	// T = (CᵢTᵢ + mcTₘ)/(Cᵢ + mc)
+	movedCapacity := mass * from.getSpecificHeat()
+	capacity := to.getHeatCapacity()
+	to.temperature = (capacity*to.temperature + movedCapacity*from.temperature) / (capacity + movedCapacity)
+	to.fluidMass += mass
+	from.fluidMass -= mass
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestMoveFluid(t *testing.T) {
+	water := fluids[waterFluidName]
+	from := &fluidSystem{name: "From", fluidMass: 10, fluid: water, temperature: 60}
+	to := &fluidSystem{name: "To", fluidMass: 10, dryHeatCapacity: 10000, fluid: water, temperature: 20}
+	energy := from.getHeatCapacity()*from.temperature + to.getHeatCapacity()*to.temperature
+
+	moveFluid(from, to, 4)
+	if from.fluidMass != 6 || to.fluidMass != 14 {
+		t.Errorf("expected 6 kg and 14 kg, got %v and %v", from.fluidMass, to.fluidMass)
+	}
+	if from.temperature != 60 {
+		t.Errorf("expected the fluid left behind to keep its temperature, got %v", from.temperature)
+	}
+	// water's specific heat barely changes between the two, so the mixed heat is conserved
+	if moved := from.getHeatCapacity()*from.temperature + to.getHeatCapacity()*to.temperature; math.Abs(moved-energy)/energy > 1e-3 {
+		t.Errorf("expected the fluid to carry its heat, got %v J vs %v J", moved, energy)
+	}
+}
+
+func TestDrainBack(t *testing.T) {
+	water := fluids[waterFluidName]
+	signal := 0.0
+	p := &pump{
+		name:          "Pump",
+		model:         fixedPumpModel,
+		ratedFlowMass: 0.1,
+		efficiency:    0.25,
+		signalType:    pwmPumpSignal,
+		signal:        func() float64 { return signal },
+		fluid:         water,
+		fluidTemp:     mockVariableIntegrator(20),
+	}
+	panel := &solarPanel{fluidSystem: fluidSystem{name: "SolarPanel", fluidMass: 3, dryHeatCapacity: 14000, fluid: water, temperature: 50}}
+	d := &drainBackSystem{
+		name:      "DrainBack",
+		panels:    []*solarPanel{panel},
+		fluidMass: 3,
+		reservoir: &fluidSystem{name: "Reservoir", fluidMass: 10, fluid: water, temperature: 20},
+		pump:      p,
+		fillTime:  2,
+		liftHead:  5,
+	}
+	p.staticHead = d.getStaticHead
+	d.initialize()
+	if panel.fluidMass != 0 || d.reservoir.fluidMass != 13 {
+		t.Fatalf("expected the collector to start drained, got %v kg in it and %v kg in the reservoir", panel.fluidMass, d.reservoir.fluidMass)
+	}
+
+	steps := []struct {
+		signal      float64
+		panelFluid  float64
+		flowMass    float64
+		staticHead  float64
+		description string
+	}{
+		{signal: 0, panelFluid: 0, flowMass: 0, staticHead: 5, description: "stays drained while the pump is off"},
+		{signal: 100, panelFluid: 0, flowMass: 0, staticHead: 5, description: "lifts the fluid while filling"},
+		{signal: 100, panelFluid: 3, flowMass: 0.1, staticHead: 0, description: "circulates once full"},
+		{signal: 100, panelFluid: 3, flowMass: 0.1, staticHead: 0, description: "keeps circulating"},
+		{signal: 0, panelFluid: 0, flowMass: 0, staticHead: 5, description: "drains when the pump stops"},
+	}
+	for i, step := range steps {
+		signal = step.signal
+		p.update(float64(i), 1)
+		d.update(float64(i), 1)
+		if panel.fluidMass != step.panelFluid || d.getFlowMass() != step.flowMass || d.getStaticHead() != step.staticHead {
+			t.Errorf("step %d %s: got %v kg, %v kg/s and %v m", i, step.description, panel.fluidMass, d.getFlowMass(), d.getStaticHead())
+		}
+		if total := panel.fluidMass + d.reservoir.fluidMass; math.Abs(total-13) > float64EqualityThreshold {
+			t.Errorf("step %d: expected 13 kg of fluid, got %v", i, total)
+		}
+	}
+	if d.fillCount != 1 || d.fillEnergy <= 0 {
+		t.Errorf("expected one fill using pump energy, got %v fills and %v J", d.fillCount, d.fillEnergy)
+	}
+}
@@ -0,0 +1,115 @@
+// hot water draws: cold mains water enters the first tank and pushes water through the tanks in series,
+// and the last tank's water is delivered to the load.
+// An optional thermostatic mixing valve tempers hot water with mains water down to a setpoint,
+// so only part of each draw is taken from the tanks.
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+// setpointTolerance is how far below the setpoint delivered water can be before the setpoint counts as unmet
+const setpointTolerance = 0.5 // K
+
+type hotWaterDraw struct {
+	name       string
+	flowRate   variableIntegrator // kg/s; requested draw, delivered at the mixing valve's outlet if there is one
+	mainsTemp  float64            // Celsius
+	supplyTemp variableIntegrator // temperature of the water leaving the last tank
+	fluid      *fluid
+	tempering  *mixingValve // optional; blends the tank water (inlet A) with mains water (inlet B)
+	setpoint   float64      // Celsius; the mixing valve's delivery temperature
+	// the current draw, updated every step
+	flowMass        float64 // kg/s; taken from the tanks
+	deliveredEnergy float64 // J; heat delivered above the mains temperature over the run
+	unmet           bool    // whether the delivered water is below the setpoint
+	unmetDuration   float64 // s; total time a draw was below the setpoint
+	warnings        []string
+	dataRecorder
+}
+
+// addMixingValve tempers the draw down to setpoint with mains water
+func (d *hotWaterDraw) addMixingValve(setpoint float64) {
+	d.setpoint = setpoint
+	d.tempering = &mixingValve{
+		component: component{
+			name: "Mixing Valve",
+		},
+		fraction:   func() float64 { return thermostaticFraction(d.setpoint, d.supplyTemp(), d.mainsTemp) },
+		inletTempA: d.supplyTemp,
+		inletTempB: func() float64 { return d.mainsTemp },
+	}
+}
+
+func (d *hotWaterDraw) getName() string {
+	return d.name
+}
+
+func (d *hotWaterDraw) getFlowMass() float64 {
+	return d.flowMass
+}
+
+func (d *hotWaterDraw) update(time float64, timeStep float64) {
+	flowRate := math.Max(0, d.flowRate())
+	deliveredTemp := d.supplyTemp()
+	hotFraction := 1.0
+	if d.tempering != nil {
+		hotFraction = d.tempering.fraction()
+		deliveredTemp = d.tempering.getOutletTemp()
+	}
+	d.flowMass = flowRate * hotFraction
+
+	// q = ṁC(Tₒᵤₜ - Tₘ), where the mains water in the mix adds no heat
+	power := flowRate * d.fluid.getSpecificHeat(deliveredTemp) * (deliveredTemp - d.mainsTemp)
+	d.deliveredEnergy += power * timeStep
+
+	if d.tempering != nil {
+		unmet := flowRate > 0 && deliveredTemp < d.setpoint-setpointTolerance
+		if unmet && !d.unmet {
+			d.warnings = append(d.warnings, fmt.Sprintf("%s could not meet the %g °C setpoint, delivering %.1f °C", d.name, d.setpoint, deliveredTemp))
+		}
+		if unmet {
+			d.unmetDuration += timeStep
+		}
+		d.unmet = unmet
+		d.addDataPoint("Hot Fraction", hotFraction)
+		d.addDataPoint("Delivered Temperature", deliveredTemp)
+	}
+	d.addDataPoint("Draw Flow Rate", flowRate)
+	d.addDataPoint("Delivered Power", power)
+}
+
+func (d *hotWaterDraw) popWarnings() []string {
+	warnings := d.warnings
+	d.warnings = nil
+	return warnings
+}
+
+// connect adds the draw's flow to the tanks, from the first tank in the series to the last
+func (d *hotWaterDraw) connect(tanks []*fluidSystem) {
+	upstreamTemp := func() float64 { return d.mainsTemp }
+	for _, tank := range tanks {
+		tank.addInflowComponent("Draw Inflow", d.getFlowMass, upstreamTemp)
+		upstreamTemp = func() float64 { return tank.temperature }
+	}
+}
+
+func (d *hotWaterDraw) getState() map[string]float64 {
+	unmet := 0.0
+	if d.unmet {
+		unmet = 1.0
+	}
+	return map[string]float64{
+		"deliveredEnergy": d.deliveredEnergy,
+		"unmet":           unmet,
+		"unmetDuration":   d.unmetDuration,
+	}
+}
+
+func (d *hotWaterDraw) setState(state map[string]float64) error {
+	d.deliveredEnergy = state["deliveredEnergy"]
+	d.unmet = state["unmet"] != 0
+	d.unmetDuration = state["unmetDuration"]
+	return nil
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestHotWaterDrawCascade(t *testing.T) {
+	water := fluids[waterFluidName]
+	preheat := fluidSystem{name: "PreheatTank", fluid: water, temperature: 30}
+	final := fluidSystem{name: "StorageTank", fluid: water, temperature: 50}
+	d := hotWaterDraw{
+		name:       "Draw",
+		flowRate:   mockVariableIntegrator(0.1),
+		mainsTemp:  10,
+		supplyTemp: func() float64 { return final.temperature },
+		fluid:      water,
+	}
+	d.connect([]*fluidSystem{&preheat, &final})
+	d.update(0, 60)
+
+	// mains water enters the preheat tank, and the preheat tank's water enters the final tank
+	preheatHeat := preheat.heatInComponents[0].(IHeatComponent).getHeat()
+	finalHeat := final.heatInComponents[0].(IHeatComponent).getHeat()
+	if math.Abs(preheatHeat-0.1*preheat.getSpecificHeat()*(10-30)) > 1e-6 {
+		t.Errorf("unexpected preheat tank inflow %v W", preheatHeat)
+	}
+	if math.Abs(finalHeat-0.1*final.getSpecificHeat()*(30-50)) > 1e-6 {
+		t.Errorf("unexpected final tank inflow %v W", finalHeat)
+	}
+
+	expectedEnergy := 0.1 * water.getSpecificHeat(50) * (50 - 10) * 60
+	if math.Abs(d.deliveredEnergy-expectedEnergy) > 1e-6 {
+		t.Errorf("expected %v J delivered, got %v J", expectedEnergy, d.deliveredEnergy)
+	}
+}
+
+func TestHotWaterDrawMixingValve(t *testing.T) {
+	water := fluids[waterFluidName]
+	tankTemp := 75.0
+	d := hotWaterDraw{
+		name:       "Draw",
+		flowRate:   mockVariableIntegrator(0.1),
+		mainsTemp:  15,
+		supplyTemp: func() float64 { return tankTemp },
+		fluid:      water,
+	}
+	d.addMixingValve(45)
+	d.update(0, 1)
+
+	// half of the draw comes from the tank, and the delivered heat is the tank water's heat above the mains
+	if math.Abs(d.flowMass-0.05) > float64EqualityThreshold {
+		t.Errorf("expected 0.05 kg/s from the tank, got %v", d.flowMass)
+	}
+	expectedEnergy := 0.1 * water.getSpecificHeat(45) * (45 - 15)
+	if math.Abs(d.deliveredEnergy-expectedEnergy) > 1e-6 {
+		t.Errorf("expected %v J delivered, got %v J", expectedEnergy, d.deliveredEnergy)
+	}
+	if warnings := d.popWarnings(); len(warnings) != 0 {
+		t.Errorf("expected no warnings, got %v", warnings)
+	}
+
+	// a cold tank raises one warning when the setpoint is first missed
+	tankTemp = 40
+	d.update(1, 1)
+	d.update(2, 1)
+	if warnings := d.popWarnings(); len(warnings) != 1 {
+		t.Errorf("expected one warning, got %v", warnings)
+	}
+	if d.flowMass != 0.1 || d.unmetDuration != 2 {
+		t.Errorf("expected the whole draw from the tank for 2 s, got %v kg/s for %v s", d.flowMass, d.unmetDuration)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestIncidenceAngleModifier(t *testing.T) {
+	m := incidenceAngleModifier{
+		angles:       []float64{0, 60, 90},
+		longitudinal: []float64{1, 0.8, 0},
+		transverse:   []float64{1, 1.2, 0},
+	}
+	if k := m.at(30, 30); math.Abs(k-0.9*1.1) > float64EqualityThreshold {
+		t.Errorf("expected %v, got %v", 0.9*1.1, k)
+	}
+	if k := m.at(95, 0); k != 0 {
+		t.Errorf("expected no transmission behind the collector, got %v", k)
+	}
+}
+
+func TestLoadIncidenceAngleModifierCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "iam.csv")
+	if err := os.WriteFile(fileName, []byte("angle,longitudinal,transverse\n0,1,1\n60,0.8,1.2\n90,0,0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loadIncidenceAngleModifierCSV(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k := m.at(60, 60); math.Abs(k-0.96) > float64EqualityThreshold {
+		t.Errorf("expected 0.96, got %v", k)
+	}
+
+	if err := os.WriteFile(fileName, []byte("0,1,1\n0,0.8,1.2\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadIncidenceAngleModifierCSV(fileName); err == nil {
+		t.Error("expected error for repeated angles")
+	}
+}
+
+func TestSolarPanelEvacuatedTubes(t *testing.T) {
+	sp := solarPanel{
+		fluidSystem: fluidSystem{
+			name:        "SolarPanel",
+			ambientTemp: 20,
+			temperature: 60,
+		},
+		panelArea:         2,
+		panelEfficiency:   0.7,
+		beamIrradiance:    mockVariableIntegrator(800),
+		diffuseIrradiance: mockVariableIntegrator(100),
+		incidence:         func() incidenceAngles { return incidenceAngles{angle: 30, longitudinal: 30, transverse: 0} },
+		tubes: &evacuatedTubes{
+			iam: incidenceAngleModifier{
+				angles:       []float64{0, 90},
+				longitudinal: []float64{1, 0},
+				transverse:   []float64{1, 0},
+			},
+			a1: 1.5,
+			a2: 0.005,
+		},
+	}
+	sp.initialize(nil, nil)
+	sp.reset()
+	sp.step()
+
+	// horizontal tubes see the diffuse radiation at 59.7°
+	diffuseModifier := 1 - 59.7/90
+	absorbed := 0.7 * 2 * (800*(1-30.0/90) + 100*diffuseModifier*diffuseModifier)
+	loss := 2 * (1.5*40 + 0.005*40*40)
+	if math.Abs(sp.getNetHeat()-(absorbed-loss)) > 1e-9 {
+		t.Errorf("expected %v W stored, got %v W", absorbed-loss, sp.getNetHeat())
+	}
+}
@@ -0,0 +1,172 @@
+// fluid properties for water and glycol mixtures.
+// Properties are tabulated against temperature and linearly interpolated, holding the end values outside the table.
+// Values are approximate, taken from ASHRAE handbook tables for mixtures by mass.
+package main
+
+import (
+	"fmt"
+	"math"
+	"sort"
+)
+
+const waterFluidName = "water"
+
+var fluidPropertyTemps = []float64{0, 20, 40, 60, 80, 100} // Celsius
+
+type fluid struct {
+	name          string
+	freezingPoint float64 // Celsius
+	boilingPoint  float64 // Celsius, at atmospheric pressure
+	// latent heats of the water in the mixture, per kg of mixture: water's latent heats times its mass fraction.
+	// The glycol neither freezes out nor boils off.
+	latentHeatFusion       float64   // J/kg
+	latentHeatVaporization float64   // J/kg
+	specificHeat           []float64 // J/(kg*K)
+	density                []float64 // kg/m^3
+	viscosity              []float64 // Pa*s
+	conductivity           []float64 // W/(m*K)
+}
+
+var fluids = map[string]*fluid{
+	waterFluidName: {
+		name:                   waterFluidName,
+		freezingPoint:          0.0,
+		boilingPoint:           100.0,
+		latentHeatFusion:       334000,
+		latentHeatVaporization: 2257000,
+		specificHeat:           []float64{4217, 4182, 4179, 4185, 4197, 4216},
+		density:                []float64{999.8, 998.2, 992.2, 983.2, 971.8, 958.4},
+		viscosity:              []float64{1.792e-3, 1.002e-3, 0.653e-3, 0.467e-3, 0.355e-3, 0.282e-3},
+		conductivity:           []float64{0.561, 0.598, 0.631, 0.654, 0.670, 0.679},
+	},
+	"propylene-glycol-25": {
+		name:                   "propylene-glycol-25",
+		freezingPoint:          -10.0,
+		boilingPoint:           101.0,
+		latentHeatFusion:       250000,
+		latentHeatVaporization: 1693000,
+		specificHeat:           []float64{3930, 3960, 3990, 4020, 4050, 4080},
+		density:                []float64{1027, 1021, 1013, 1003, 990, 975},
+		viscosity:              []float64{4.3e-3, 2.2e-3, 1.25e-3, 0.8e-3, 0.57e-3, 0.43e-3},
+		conductivity:           []float64{0.46, 0.48, 0.50, 0.51, 0.52, 0.52},
+	},
+	"propylene-glycol-40": {
+		name:                   "propylene-glycol-40",
+		freezingPoint:          -21.0,
+		boilingPoint:           103.0,
+		latentHeatFusion:       200000,
+		latentHeatVaporization: 1354000,
+		specificHeat:           []float64{3680, 3730, 3780, 3830, 3880, 3930},
+		density:                []float64{1044, 1036, 1027, 1016, 1004, 990},
+		viscosity:              []float64{10.8e-3, 4.7e-3, 2.4e-3, 1.4e-3, 0.9e-3, 0.65e-3},
+		conductivity:           []float64{0.40, 0.41, 0.42, 0.43, 0.43, 0.44},
+	},
+	"propylene-glycol-50": {
+		name:                   "propylene-glycol-50",
+		freezingPoint:          -33.0,
+		boilingPoint:           104.0,
+		latentHeatFusion:       167000,
+		latentHeatVaporization: 1128500,
+		specificHeat:           []float64{3520, 3575, 3630, 3690, 3745, 3800},
+		density:                []float64{1051, 1042, 1032, 1020, 1008, 995},
+		viscosity:              []float64{18.0e-3, 6.4e-3, 3.0e-3, 1.65e-3, 1.05e-3, 0.72e-3},
+		conductivity:           []float64{0.36, 0.37, 0.37, 0.38, 0.38, 0.39},
+	},
+	"ethylene-glycol-25": {
+		name:                   "ethylene-glycol-25",
+		freezingPoint:          -12.0,
+		boilingPoint:           101.0,
+		latentHeatFusion:       250000,
+		latentHeatVaporization: 1693000,
+		specificHeat:           []float64{3760, 3800, 3840, 3880, 3920, 3960},
+		density:                []float64{1040, 1033, 1024, 1013, 1000, 986},
+		viscosity:              []float64{3.5e-3, 1.9e-3, 1.15e-3, 0.77e-3, 0.56e-3, 0.43e-3},
+		conductivity:           []float64{0.47, 0.49, 0.51, 0.52, 0.53, 0.53},
+	},
+	"ethylene-glycol-40": {
+		name:                   "ethylene-glycol-40",
+		freezingPoint:          -24.0,
+		boilingPoint:           104.0,
+		latentHeatFusion:       200000,
+		latentHeatVaporization: 1354000,
+		specificHeat:           []float64{3460, 3510, 3560, 3610, 3660, 3710},
+		density:                []float64{1063, 1054, 1043, 1031, 1017, 1002},
+		viscosity:              []float64{5.9e-3, 3.0e-3, 1.8e-3, 1.15e-3, 0.8e-3, 0.6e-3},
+		conductivity:           []float64{0.40, 0.42, 0.43, 0.44, 0.45, 0.45},
+	},
+	"ethylene-glycol-50": {
+		name:                   "ethylene-glycol-50",
+		freezingPoint:          -37.0,
+		boilingPoint:           107.0,
+		latentHeatFusion:       167000,
+		latentHeatVaporization: 1128500,
+		specificHeat:           []float64{3280, 3340, 3400, 3460, 3520, 3580},
+		density:                []float64{1079, 1069, 1057, 1044, 1030, 1015},
+		viscosity:              []float64{8.9e-3, 4.2e-3, 2.4e-3, 1.5e-3, 1.0e-3, 0.75e-3},
+		conductivity:           []float64{0.37, 0.38, 0.39, 0.40, 0.40, 0.41},
+	},
+}
+
+func getFluid(name string) (*fluid, error) {
+	if f, ok := fluids[name]; ok {
+		return f, nil
+	}
+	names := make([]string, 0, len(fluids))
+	for fluidName := range fluids {
+		names = append(names, fluidName)
+	}
+	sort.Strings(names)
+	return nil, fmt.Errorf("unknown fluid %q, expected one of %v", name, names)
+}
+
+func (f *fluid) getSpecificHeat(temp float64) float64 {
+	return interpolateFluidProperty(f.specificHeat, temp)
+}
+
+func (f *fluid) getDensity(temp float64) float64 {
+	return interpolateFluidProperty(f.density, temp)
+}
+
+func (f *fluid) getViscosity(temp float64) float64 {
+	return interpolateFluidProperty(f.viscosity, temp)
+}
+
+func (f *fluid) getConductivity(temp float64) float64 {
+	return interpolateFluidProperty(f.conductivity, temp)
+}
+
+// getEnthalpy returns the liquid's sensible heat in J/kg relative to 0 °C, integrating the tabulated specific heat
+func (f *fluid) getEnthalpy(temp float64) float64 {
+	from, to, sign := 0.0, temp, 1.0
+	if temp < 0 {
+		from, to, sign = temp, 0.0, -1.0
+	}
+	// the specific heat is linear between the table's temperatures, so each segment integrates exactly
+	enthalpy, lower := 0.0, from
+	for _, upper := range append(append([]float64{}, fluidPropertyTemps...), to) {
+		upper = math.Min(upper, to)
+		if upper <= lower {
+			continue
+		}
+		enthalpy += (upper - lower) * (f.getSpecificHeat(lower) + f.getSpecificHeat(upper)) / 2
+		lower = upper
+	}
+	return sign * enthalpy
+}
+
+func interpolateFluidProperty(values []float64, temp float64) float64 {
+	return interpolateTable(fluidPropertyTemps, values, temp)
+}
+
+// interpolateTable linearly interpolates values at x, where xs is ascending. The end values are held outside the table.
+func interpolateTable(xs []float64, values []float64, x float64) float64 {
+	i := sort.SearchFloat64s(xs, x)
+	if i == 0 {
+		return values[0]
+	}
+	if i == len(xs) {
+		return values[len(values)-1]
+	}
+	fraction := (x - xs[i-1]) / (xs[i] - xs[i-1])
+	return values[i-1] + fraction*(values[i]-values[i-1])
+}
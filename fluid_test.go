@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestFluid_Interpolation(t *testing.T) {
+	water, err := getFluid(waterFluidName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		value    float64
+		expected float64
+	}{
+		{name: "Table Point", value: water.getSpecificHeat(20.0), expected: 4182},
+		{name: "Between Points", value: water.getDensity(30.0), expected: (998.2 + 992.2) / 2},
+		{name: "Below Table", value: water.getViscosity(-5.0), expected: 1.792e-3},
+		{name: "Above Table", value: water.getConductivity(120.0), expected: 0.679},
+		{name: "Enthalpy", value: water.getEnthalpy(30.0), expected: 20*(4217+4182)/2 + 10*(4182+4180.5)/2},
+		{name: "Enthalpy Below Zero", value: water.getEnthalpy(-5.0), expected: -5 * 4217},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if math.Abs(tt.value-tt.expected) > 1e-9 {
+				t.Errorf("expected %v, got %v", tt.expected, tt.value)
+			}
+		})
+	}
+}
+
+func TestFluid_Glycol(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	glycol, err := getFluid("propylene-glycol-40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if glycol.freezingPoint >= water.freezingPoint {
+		t.Errorf("expected glycol to freeze below water, got %v", glycol.freezingPoint)
+	}
+	if glycol.getSpecificHeat(40.0) >= water.getSpecificHeat(40.0) {
+		t.Error("expected glycol to have a lower specific heat than water")
+	}
+	if glycol.getViscosity(0.0) <= water.getViscosity(0.0) {
+		t.Error("expected glycol to be more viscous than water")
+	}
+
+	if _, err := getFluid("brine"); err == nil {
+		t.Error("expected error for an unknown fluid")
+	}
+}
+
+func TestFluid_GlycolLatentHeat(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	// only the water in a mixture freezes or boils, so its latent heats scale with the water's mass fraction
+	for name, f := range fluids {
+		if name == waterFluidName {
+			continue
+		}
+		percent, err := strconv.Atoi(name[strings.LastIndex(name, "-")+1:])
+		if err != nil {
+			t.Fatalf("expected a glycol percentage in %q", name)
+		}
+		waterFraction := 1 - float64(percent)/100
+		if math.Abs(f.latentHeatFusion-waterFraction*water.latentHeatFusion)/f.latentHeatFusion > 0.005 {
+			t.Errorf("expected %v to freeze with %v J/kg, got %v J/kg", name, waterFraction*water.latentHeatFusion, f.latentHeatFusion)
+		}
+		if math.Abs(f.latentHeatVaporization-waterFraction*water.latentHeatVaporization)/f.latentHeatVaporization > 0.005 {
+			t.Errorf("expected %v to boil with %v J/kg, got %v J/kg", name, waterFraction*water.latentHeatVaporization, f.latentHeatVaporization)
+		}
+	}
+
+	// boiling off the 1.2 kg of water in 2 kg of 40 % propylene glycol takes 1.2 × 2257 kJ
+	glycol, _ := getFluid("propylene-glycol-40")
+	fs := fluidSystem{fluidMass: 2, fluid: glycol, temperature: glycol.boilingPoint}
+	fs.storeHeat(1.2 * 2257000)
+	if math.Abs(fs.vaporFraction-1) > 1e-3 || math.Abs(fs.temperature-glycol.boilingPoint) > 0.1 {
+		t.Errorf("expected the water to just boil off at %v °C, got %v vaporized at %v °C", glycol.boilingPoint, fs.vaporFraction, fs.temperature)
+	}
+}
+
+func TestFluidSystem_CommitWithFluid(t *testing.T) {
+	glycol, _ := getFluid("ethylene-glycol-50")
+	fs := fluidSystem{
+		fluidMass:   2.0,
+		fluid:       glycol,
+		temperature: 40.0,
+		stepHeatIn:  []float64{1000.0},
+	}
+	fs.commit(1.0)
+	expectedTemp := 40.0 + 1000.0/(2.0*3400.0)
+	if math.Abs(fs.temperature-expectedTemp) > 1e-9 {
+		t.Errorf("expected %v, got %v", expectedTemp, fs.temperature)
+	}
+}
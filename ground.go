@@ -0,0 +1,169 @@
+// ground: buried tanks and pipes lose heat to the soil instead of the air.
+// The undisturbed soil temperature follows Kusuda's model, where the annual and daily surface temperature waves
+// are damped and delayed with depth. A finite-difference ring of soil around a buried element lets the soil
+// warm up from the element's losses, which the undisturbed temperature alone would ignore.
+package main
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+// kusudaGround is the undisturbed soil temperature:
+// T(z, t) = Tₘ - Aₐe^(-z/dₐ)cos(2π(t - t₀)/Pₐ - z/dₐ) - A_de^(-z/d_d)cos(2π(t - t₀)/P_d - z/d_d),
+// where the damping depth is d = √(Pα/π)
+type kusudaGround struct {
+	meanTemp        float64 // Celsius
+	annualAmplitude float64 // K
+	dailyAmplitude  float64 // K
+	diffusivity     float64 // m^2/s; α
+	conductivity    float64 // W/(m*K)
+	coldestDay      float64 // day of the year the surface is coldest
+	coldestHour     float64 // hour of the day the surface is coldest
+}
+
+// tempAt returns the soil temperature at a depth in m, and a time given as a fractional day of the year
+func (g kusudaGround) tempAt(depth float64, day float64) float64 {
+	wave := func(amplitude float64, period float64, phase float64) float64 {
+		// the period and phase are in days
+		dampingDepth := math.Sqrt(period * 24 * 60 * 60 * g.diffusivity / math.Pi)
+		return amplitude * math.Exp(-depth/dampingDepth) * math.Cos(2*math.Pi*(day-phase)/period-depth/dampingDepth)
+	}
+	return g.meanTemp - wave(g.annualAmplitude, 365, g.coldestDay) - wave(g.dailyAmplitude, 1, g.coldestHour/24)
+}
+
+// getVolumetricHeatCapacity is the soil's ρc = k/α
+func (g kusudaGround) getVolumetricHeatCapacity() float64 {
+	return g.conductivity / g.diffusivity
+}
+
+// undisturbedGround is the soil at a depth as an ambient for buried systems. The soil is large enough that
+// the heat lost to it doesn't change its temperature.
+type undisturbedGround struct {
+	name  string
+	depth float64 // m
+	soil  kusudaGround
+	day   variableIntegrator // fractional day of the year
+}
+
+func (g *undisturbedGround) reset() {}
+
+func (g *undisturbedGround) step() {}
+
+func (g *undisturbedGround) commit(timeStep float64) {}
+
+func (g *undisturbedGround) getName() string {
+	return g.name
+}
+
+func (g *undisturbedGround) getTemp() float64 {
+	return g.soil.tempAt(g.depth, g.day())
+}
+
+func (g *undisturbedGround) getData() map[string]*[]opts.LineData {
+	return nil
+}
+
+func (g *undisturbedGround) inputHeatCallback(heat float64) {}
+
+// newSoilRing builds concentric cylinders of soil around a buried element, from its outer radius out to ringRadius,
+// where the soil is undisturbed. The nodes are spaced logarithmically, so they are thinnest next to the element.
+// The first node is the element's ambient. The nodes are integrated explicitly, which is only stable while each
+// node's time constant C/ΣG is longer than the time step, so a ring with thinner nodes is rejected.
+func newSoilRing(name string, innerRadius float64, ringRadius float64, length float64, nodeCount int, ground *undisturbedGround, timeStep float64) ([]*fluidSystem, error) {
+	radii := make([]float64, nodeCount+1)
+	for i := range radii {
+		radii[i] = innerRadius * math.Pow(ringRadius/innerRadius, float64(i)/float64(nodeCount))
+	}
+	// each node's temperature is at the log mean of its radii
+	centers := make([]float64, nodeCount)
+	for i := range centers {
+		centers[i] = math.Sqrt(radii[i] * radii[i+1])
+	}
+	// a cylindrical shell conducts G = 2πkL/ln(r₂/r₁)
+	conductance := func(r1 float64, r2 float64) float64 {
+		return 2 * math.Pi * ground.soil.conductivity * length / math.Log(r2/r1)
+	}
+
+	nodes := make([]*fluidSystem, nodeCount)
+	for i := range nodes {
+		nodes[i] = &fluidSystem{
+			name:            fmt.Sprintf("%sSoil%d", name, i+1),
+			dryHeatCapacity: ground.soil.getVolumetricHeatCapacity() * math.Pi * (radii[i+1]*radii[i+1] - radii[i]*radii[i]) * length,
+			temperature:     ground.getTemp(),
+		}
+	}
+	for i, node := range nodes {
+		outerRadius := ringRadius
+		if i < nodeCount-1 {
+			outerRadius = centers[i+1]
+		}
+		totalConductance := conductance(centers[i], outerRadius)
+		if i > 0 {
+			totalConductance += conductance(centers[i-1], centers[i])
+		}
+		if timeConstant := node.dryHeatCapacity / totalConductance; timeConstant <= timeStep {
+			return nil, fmt.Errorf("%s has a time constant of %.0f s, shorter than the %v s time step", node.name, timeConstant, timeStep)
+		}
+	}
+	for i, node := range nodes {
+		node.heatInComponents = []IComponent{}
+		if i == nodeCount-1 {
+			node.heatOutComponents = []IComponent{
+				conductanceHeatComponent{
+					component: component{
+						name: "Soil Conduction",
+					},
+					conductance: conductance(centers[i], ringRadius),
+					currentTemp: func() float64 { return node.temperature },
+					outputTemp:  ground.getTemp,
+				},
+			}
+			continue
+		}
+		outer := nodes[i+1]
+		node.heatOutComponents = []IComponent{
+			transferHeatComponentWrapper{
+				component: component{
+					name: "Soil Conduction",
+				},
+				wrappedComponent: conductanceHeatComponent{
+					conductance: conductance(centers[i], centers[i+1]),
+					currentTemp: func() float64 { return node.temperature },
+					outputTemp:  func() float64 { return outer.temperature },
+				},
+				output: outer,
+			},
+		}
+	}
+	return nodes, nil
+}
+
+// buriedPipeLossComponent is the heat a pipe loses to the ground between a system and the next one downstream.
+// The fluid cools exponentially along the pipe: q = ṁC(T - T_g)(1 - e^(-UA/(ṁC))).
+// Several systems can feed the pipe, each losing its share of the flow's heat.
+type buriedPipeLossComponent struct {
+	component
+	conductance  float64            // W/K; UA of the whole pipe
+	flowMass     variableIntegrator // kg/s; from this system
+	pipeFlowMass variableIntegrator // kg/s; through the whole pipe
+	specificHeat variableIntegrator
+	currentTemp  variableIntegrator
+	groundTemp   variableIntegrator
+}
+
+func (c buriedPipeLossComponent) getHeat() float64 {
+	pipeCapacityRate := c.pipeFlowMass() * c.specificHeat()
+	if pipeCapacityRate <= 0 {
+		return 0.0
+	}
+	return c.flowMass() * c.specificHeat() * (c.currentTemp() - c.groundTemp()) * (1 - math.Exp(-c.conductance/pipeCapacityRate))
+}
+
+// insulatedPipeConductance is the UA of a pipe's insulation: G = 2πkL/ln(rₒ/rᵢ)
+func insulatedPipeConductance(diameter float64, insulationThickness float64, insulationConductivity float64, length float64) float64 {
+	radius := diameter / 2
+	return 2 * math.Pi * insulationConductivity * length / math.Log((radius+insulationThickness)/radius)
+}
@@ -0,0 +1,132 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestKusudaGround(t *testing.T) {
+	soil := kusudaGround{
+		meanTemp:        10,
+		annualAmplitude: 10,
+		dailyAmplitude:  5,
+		diffusivity:     0.05 / (24 * 60 * 60),
+		conductivity:    1.5,
+		coldestDay:      35,
+		coldestHour:     5,
+	}
+
+	// the surface is coldest on the coldest day and hour, with both waves at their troughs
+	if surface := soil.tempAt(0, 35+5.0/24); math.Abs(surface-(10-10-5)) > 1e-3 {
+		t.Errorf("expected the surface at -5 °C, got %v °C", surface)
+	}
+	// deep down, both waves have died out
+	if deep := soil.tempAt(30, 200.5); math.Abs(deep-10) > 1e-3 {
+		t.Errorf("expected the deep soil at the mean temperature, got %v °C", deep)
+	}
+	// the daily wave is gone at a metre, while the annual one is damped and delayed
+	shallow := soil.tempAt(1, 35)
+	if shallow <= 0 || shallow >= 10 {
+		t.Errorf("expected the soil at 1 m between the surface minimum and the mean, got %v °C", shallow)
+	}
+	annualOnly := soil
+	annualOnly.dailyAmplitude = 0
+	for _, day := range []float64{35, 35.25, 35.5, 35.75} {
+		if math.Abs(soil.tempAt(1, day)-annualOnly.tempAt(1, day)) > 1e-2 {
+			t.Errorf("expected no daily swing at 1 m on day %v", day)
+		}
+	}
+}
+
+func TestSoilRing(t *testing.T) {
+	ground := &undisturbedGround{
+		name:  "Ground",
+		depth: 30,
+		soil: kusudaGround{
+			meanTemp:     10,
+			diffusivity:  1e-6,
+			conductivity: 1.5,
+		},
+		day: mockVariableIntegrator(0),
+	}
+	nodes, err := newSoilRing("Tank", 0.5, 2, 1, 4, ground, 60)
+	if err != nil || len(nodes) != 4 || nodes[0].getName() != "TankSoil1" {
+		t.Fatalf("unexpected soil ring %v (%v)", nodes, err)
+	}
+	// the nodes fill the ring's volume
+	capacity := 0.0
+	for _, node := range nodes {
+		capacity += node.dryHeatCapacity
+		if node.temperature != 10 {
+			t.Errorf("expected %v to start at the undisturbed temperature, got %v °C", node.getName(), node.temperature)
+		}
+	}
+	expected := 1.5 / 1e-6 * math.Pi * (2*2 - 0.5*0.5)
+	if math.Abs(capacity-expected)/expected > 1e-9 {
+		t.Errorf("expected a heat capacity of %v J/K, got %v J/K", expected, capacity)
+	}
+
+	// a warm inner node conducts to the next one, and nothing leaves the ring until the outer node warms up
+	nodes[0].temperature = 30
+	systems := []ISystem{}
+	for _, node := range nodes {
+		systems = append(systems, node)
+	}
+	for _, sys := range systems {
+		sys.reset()
+	}
+	for _, sys := range systems {
+		sys.step()
+	}
+	for _, sys := range systems {
+		sys.commit(60)
+	}
+	if math.Abs(nodes[0].getNetHeat()+nodes[1].getNetHeat()) > 1e-9 {
+		t.Errorf("expected the heat conducted out of the first node to reach the second, got %v W and %v W",
+			nodes[0].getNetHeat(), nodes[1].getNetHeat())
+	}
+	if nodes[0].getNetHeat() >= 0 || nodes[3].getNetHeat() != 0 {
+		t.Errorf("unexpected net heats %v W and %v W", nodes[0].getNetHeat(), nodes[3].getNetHeat())
+	}
+
+	// the first shell around a thin pipe holds about 1500 s of heat, too little for an hourly step
+	if _, err := newSoilRing("Pipe", 0.028, 2, 1, 5, ground, 60*60); err == nil {
+		t.Error("expected a soil ring too thin for the time step to be rejected")
+	}
+}
+
+func TestBuriedPipeLoss(t *testing.T) {
+	// G = 2πkL/ln(rₒ/rᵢ)
+	ua := insulatedPipeConductance(0.02, 0.01, 0.04, 10)
+	if math.Abs(ua-2*math.Pi*0.04*10/math.Log(2)) > 1e-9 {
+		t.Errorf("unexpected pipe conductance %v W/K", ua)
+	}
+
+	c := buriedPipeLossComponent{
+		conductance:  ua,
+		flowMass:     mockVariableIntegrator(0.05),
+		pipeFlowMass: mockVariableIntegrator(0.05),
+		specificHeat: mockVariableIntegrator(4186),
+		currentTemp:  mockVariableIntegrator(60),
+		groundTemp:   mockVariableIntegrator(10),
+	}
+	capacityRate := 0.05 * 4186
+	expected := capacityRate * 50 * (1 - math.Exp(-ua/capacityRate))
+	if math.Abs(c.getHeat()-expected) > 1e-9 {
+		t.Errorf("expected %v W, got %v W", expected, c.getHeat())
+	}
+	// a low flow can't lose more than cools it all the way to the ground
+	c.flowMass, c.pipeFlowMass = mockVariableIntegrator(1e-6), mockVariableIntegrator(1e-6)
+	if c.getHeat() > 1e-6*4186*50+1e-9 {
+		t.Errorf("expected at most %v W, got %v W", 1e-6*4186*50, c.getHeat())
+	}
+	// a branch feeding half the pipe's flow loses half the heat
+	c.flowMass, c.pipeFlowMass = mockVariableIntegrator(0.025), mockVariableIntegrator(0.05)
+	if math.Abs(c.getHeat()-expected/2) > 1e-9 {
+		t.Errorf("expected %v W, got %v W", expected/2, c.getHeat())
+	}
+	c.flowMass, c.pipeFlowMass = mockVariableIntegrator(0), mockVariableIntegrator(0)
+	if c.getHeat() != 0 {
+		t.Errorf("expected no loss without flow, got %v W", c.getHeat())
+	}
+}
@@ -0,0 +1,126 @@
+// heat exchangers couple two separate fluid loops using the effectiveness-NTU method,
+// e.g. a glycol collector loop and potable water in the storage tank
+package main
+
+import "math"
+
+const (
+	// counterflow is used for external plate heat exchangers, with flow on both sides
+	counterflowHeatExchanger = "counterflow"
+	// an immersed coil sits in a tank of stagnant fluid, so the tank side has an infinite heat capacity rate
+	immersedCoilHeatExchanger = "coil"
+	// a plate heat exchanger is a counterflow heat exchanger outside the tank, with its tank side pumped
+	plateHeatExchanger = "plate"
+)
+
+type heatExchangerComponent struct {
+	component
+	arrangement        string
+	ua                 float64            // W/K
+	flowMass           variableIntegrator // kg/s; this side of the heat exchanger
+	specificHeat       variableIntegrator // J/(kg*K)
+	outputFlowMass     variableIntegrator // kg/s; the other side, unused for an immersed coil
+	outputSpecificHeat variableIntegrator // J/(kg*K); unused for an immersed coil
+	currentTemp        variableIntegrator // inlet temperature on this side
+	outputTemp         variableIntegrator // inlet temperature on the other side
+}
+
+func (c heatExchangerComponent) getHeat() float64 {
+	// q = εC_min(Tᵢ - Tₒ)
+	capacityRate := c.flowMass() * c.specificHeat()
+	if capacityRate <= 0 {
+		return 0.0
+	}
+	if c.arrangement == immersedCoilHeatExchanger {
+		return immersedCoilEffectiveness(c.ua/capacityRate) * capacityRate * (c.currentTemp() - c.outputTemp())
+	}
+
+	outputCapacityRate := c.outputFlowMass() * c.outputSpecificHeat()
+	if outputCapacityRate <= 0 {
+		return 0.0
+	}
+	minCapacityRate := math.Min(capacityRate, outputCapacityRate)
+	maxCapacityRate := math.Max(capacityRate, outputCapacityRate)
+	effectiveness := counterflowEffectiveness(c.ua/minCapacityRate, minCapacityRate/maxCapacityRate)
+	return effectiveness * minCapacityRate * (c.currentTemp() - c.outputTemp())
+}
+
+// counterflowEffectiveness is ε for a counterflow heat exchanger with the given NTU and capacity ratio
+func counterflowEffectiveness(ntu float64, capacityRatio float64) float64 {
+	if math.Abs(1-capacityRatio) < float64EqualityThreshold {
+		return ntu / (1 + ntu)
+	}
+	e := math.Exp(-ntu * (1 - capacityRatio))
+	return (1 - e) / (1 - capacityRatio*e)
+}
+
+// immersedCoilEffectiveness is ε when the other side's capacity rate is infinite: ε = 1 - e^(-NTU)
+func immersedCoilEffectiveness(ntu float64) float64 {
+	return 1 - math.Exp(-ntu)
+}
+
+// tubeInnerHTC is the heat transfer coefficient inside a tube: Nu = 3.66 for laminar flow,
+// and the Dittus-Boelter correlation for the cooled fluid in turbulent flow
+func tubeInnerHTC(f *fluid, temp float64, flowMass float64, diameter float64) float64 {
+	viscosity := f.getViscosity(temp)
+	conductivity := f.getConductivity(temp)
+	reynolds := 4 * flowMass / (math.Pi * diameter * viscosity)
+	prandtl := viscosity * f.getSpecificHeat(temp) / conductivity
+
+	nusselt := 3.66
+	if reynolds > 2300 {
+		nusselt = 0.023 * math.Pow(reynolds, 0.8) * math.Pow(prandtl, 0.3)
+	}
+	return nusselt * conductivity / diameter
+}
+
+// coilUA is the UA of a thin-walled coil from its geometry: 1/U = 1/hᵢ + 1/hₒ, A = πdL
+func coilUA(length float64, diameter float64, innerHTC float64, outerHTC float64) float64 {
+	return math.Pi * diameter * length / (1/innerHTC + 1/outerHTC)
+}
+
+// plateHeatExchangerUA is the UA of a plate heat exchanger; the two end plates don't transfer heat
+func plateHeatExchangerUA(plateCount int, plateArea float64, overallHTC float64) float64 {
+	return float64(max(plateCount-2, 0)) * plateArea * overallHTC
+}
+
+// addImmersedCoilComponent passes the system's loop through a coil immersed in the tank
+func (fs *fluidSystem) addImmersedCoilComponent(tank IFluidSystem, ua float64, flowRate variableIntegrator) {
+	fs.heatOutComponents = append(fs.heatOutComponents,
+		transferHeatComponentWrapper{
+			component: component{
+				name: "Heat Exchanger Output",
+			},
+			wrappedComponent: heatExchangerComponent{
+				arrangement:  immersedCoilHeatExchanger,
+				ua:           ua,
+				flowMass:     flowRate,
+				specificHeat: func() float64 { return (*fs).getSpecificHeat() },
+				currentTemp:  func() float64 { return (*fs).temperature },
+				outputTemp:   func() float64 { return tank.getTemp() },
+			},
+			output: tank,
+		})
+}
+
+// addPlateHeatExchangerComponent couples the system's loop to another loop through an external counterflow
+// plate heat exchanger, with flow on both sides
+func (fs *fluidSystem) addPlateHeatExchangerComponent(output IFluidSystem, ua float64, flowRate variableIntegrator, outputFlowRate variableIntegrator, outputSpecificHeat variableIntegrator) {
+	fs.heatOutComponents = append(fs.heatOutComponents,
+		transferHeatComponentWrapper{
+			component: component{
+				name: "Heat Exchanger Output",
+			},
+			wrappedComponent: heatExchangerComponent{
+				arrangement:        counterflowHeatExchanger,
+				ua:                 ua,
+				flowMass:           flowRate,
+				specificHeat:       func() float64 { return (*fs).getSpecificHeat() },
+				outputFlowMass:     outputFlowRate,
+				outputSpecificHeat: outputSpecificHeat,
+				currentTemp:        func() float64 { return (*fs).temperature },
+				outputTemp:         func() float64 { return output.getTemp() },
+			},
+			output: output,
+		})
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTubeInnerHTC(t *testing.T) {
+	// water at 20 °C: μ = 1.002e-3 Pa·s, k = 0.598 W/(m·K), c = 4182 J/(kg·K), so Pr = μc/k = 7.0073
+	water := fluids[waterFluidName]
+	tests := []struct {
+		name     string
+		flowMass float64
+		expected float64
+	}{
+		// Re = 4ṁ/(πdμ) = 635, so Nu = 3.66 and h = 3.66 × 0.598/0.02
+		{name: "Laminar", flowMass: 0.01, expected: 109.434},
+		// Re = 12707, so Nu = 0.023 × 12707^0.8 × 7.0073^0.3 = 79.182 and h = 79.182 × 0.598/0.02
+		{name: "Turbulent", flowMass: 0.2, expected: 2367.54},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if htc := tubeInnerHTC(water, 20, tt.flowMass, 0.02); math.Abs(htc-tt.expected) > 0.01 {
+				t.Errorf("expected %v, got %v", tt.expected, htc)
+			}
+		})
+	}
+}
+
+func TestCoilUA(t *testing.T) {
+	tests := []struct {
+		name     string
+		innerHTC float64
+		outerHTC float64
+		expected float64
+	}{
+		// A = π × 0.022 × 12 = 0.82938 m², U = 1/(1/1000 + 1/400) = 285.71
+		{name: "Both sides", innerHTC: 1000, outerHTC: 400, expected: 236.966},
+		// U = 1/(2/500) = 250
+		{name: "Equal sides", innerHTC: 500, outerHTC: 500, expected: 207.345},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ua := coilUA(12, 0.022, tt.innerHTC, tt.outerHTC); math.Abs(ua-tt.expected) > 1e-3 {
+				t.Errorf("expected %v, got %v", tt.expected, ua)
+			}
+		})
+	}
+}
+
+func TestPlateHeatExchangerUA(t *testing.T) {
+	tests := []struct {
+		name       string
+		plateCount int
+		expected   float64
+	}{
+		// 18 of the 20 plates transfer heat: 18 × 0.05 × 2500
+		{name: "Twenty plates", plateCount: 20, expected: 2250},
+		{name: "End plates only", plateCount: 2, expected: 0},
+		{name: "Too few plates", plateCount: 1, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if ua := plateHeatExchangerUA(tt.plateCount, 0.05, 2500); math.Abs(ua-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected %v, got %v", tt.expected, ua)
+			}
+		})
+	}
+}
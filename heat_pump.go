@@ -0,0 +1,216 @@
+// heat pump water heater: moves heat from an ambient zone into a tank.
+// The COP and heating capacity come from manufacturer-style maps of source (air) temperature by sink (tank) temperature,
+// and a thermostat with a deadband switches the compressor on and off.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	indoorHeatPumpSource  = "indoor"  // the heat pump takes heat from the tank's room
+	outdoorHeatPumpSource = "outdoor" // a split unit takes heat from the outdoor air
+)
+
+// performanceMap is a table of values by source and sink temperature, interpolated bilinearly.
+// Values are held at the map's edges.
+type performanceMap struct {
+	sourceTemps []float64   // Celsius; ascending
+	sinkTemps   []float64   // Celsius; ascending
+	values      [][]float64 // values[i][j] is at sourceTemps[i] and sinkTemps[j]
+}
+
+func (m performanceMap) at(sourceTemp float64, sinkTemp float64) float64 {
+	sourceValues := make([]float64, len(m.sourceTemps))
+	for i, row := range m.values {
+		sourceValues[i] = interpolateTable(m.sinkTemps, row, sinkTemp)
+	}
+	return interpolateTable(m.sourceTemps, sourceValues, sourceTemp)
+}
+
+// defaultHeatPumpCOP is typical of an integrated air source heat pump water heater
+var defaultHeatPumpCOP = performanceMap{
+	sourceTemps: []float64{-7, 2, 7, 15, 20, 35},
+	sinkTemps:   []float64{15, 35, 45, 55, 65},
+	values: [][]float64{
+		{2.6, 2.0, 1.7, 1.4, 1.1},
+		{3.2, 2.5, 2.1, 1.8, 1.4},
+		{3.8, 2.9, 2.5, 2.1, 1.7},
+		{4.6, 3.5, 3.0, 2.5, 2.0},
+		{5.1, 3.9, 3.3, 2.8, 2.2},
+		{6.3, 4.8, 4.0, 3.4, 2.7},
+	},
+}
+
+// defaultHeatPumpCapacity is the heating capacity as a fraction of the rated capacity, rated at 15 °C air and 45 °C water
+var defaultHeatPumpCapacity = performanceMap{
+	sourceTemps: []float64{-7, 2, 7, 15, 20, 35},
+	sinkTemps:   []float64{15, 35, 45, 55, 65},
+	values: [][]float64{
+		{0.65, 0.62, 0.60, 0.57, 0.54},
+		{0.83, 0.80, 0.78, 0.75, 0.71},
+		{0.94, 0.90, 0.88, 0.85, 0.81},
+		{1.08, 1.03, 1.00, 0.96, 0.91},
+		{1.16, 1.10, 1.07, 1.03, 0.98},
+		{1.35, 1.28, 1.24, 1.19, 1.13},
+	},
+}
+
+// positiveCOP checks a COP map's values; the electrical power is the heat output divided by the COP
+func positiveCOP(value float64) error {
+	if value <= 0 {
+		return errors.New("COP must be positive")
+	}
+	return nil
+}
+
+// nonNegativeCapacity checks a capacity map's values
+func nonNegativeCapacity(value float64) error {
+	if value < 0 {
+		return errors.New("capacity fraction can't be negative")
+	}
+	return nil
+}
+
+// loadPerformanceMapCSV reads a map whose header row holds the sink temperatures after a label,
+// e.g. "source,15,35,55", and whose rows hold a source temperature followed by a value for each sink temperature.
+// checkValue validates each value in the map.
+func loadPerformanceMapCSV(fileName string, checkValue func(value float64) error) (performanceMap, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return performanceMap{}, err
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return performanceMap{}, err
+	}
+	if len(rows) < 2 || len(rows[0]) < 2 {
+		return performanceMap{}, errors.New(fileName + ": map needs a header row of sink temperatures and at least one row")
+	}
+
+	m := performanceMap{}
+	parse := func(cells []string, line int) ([]float64, error) {
+		values := make([]float64, len(cells))
+		for i, cell := range cells {
+			value, err := strconv.ParseFloat(cell, 64)
+			if err != nil {
+				return nil, fmt.Errorf("%s line %d: could not parse %q", fileName, line, cell)
+			}
+			if i > 0 && line == 1 && value <= values[i-1] {
+				return nil, fmt.Errorf("%s line %d: sink temperatures must be increasing", fileName, line)
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+	if m.sinkTemps, err = parse(rows[0][1:], 1); err != nil {
+		return performanceMap{}, err
+	}
+	for i, row := range rows[1:] {
+		line := i + 2
+		if len(row) != len(m.sinkTemps)+1 {
+			return performanceMap{}, fmt.Errorf("%s line %d: expected a source temperature and %d values", fileName, line, len(m.sinkTemps))
+		}
+		values, err := parse(row, line)
+		if err != nil {
+			return performanceMap{}, err
+		}
+		if len(m.sourceTemps) > 0 && values[0] <= m.sourceTemps[len(m.sourceTemps)-1] {
+			return performanceMap{}, fmt.Errorf("%s line %d: source temperatures must be increasing", fileName, line)
+		}
+		for _, value := range values[1:] {
+			if err := checkValue(value); err != nil {
+				return performanceMap{}, fmt.Errorf("%s line %d: %v, got %v", fileName, line, err, value)
+			}
+		}
+		m.sourceTemps = append(m.sourceTemps, values[0])
+		m.values = append(m.values, values[1:])
+	}
+	return m, nil
+}
+
+// heatPump is both a controller, switching the compressor every step, and the heat component that delivers its output to the tank
+type heatPump struct {
+	name          string
+	ratedCapacity float64 // W; heating output where the capacity map is 1
+	cop           performanceMap
+	capacity      performanceMap // fraction of ratedCapacity
+	setpoint      float64        // Celsius; the compressor stops once the tank reaches it
+	deadband      float64        // K; the compressor starts once the tank is this far below the setpoint
+	maxSinkTemp   float64        // Celsius; no heating above this tank temperature
+	minSourceTemp float64        // Celsius; no heating below this source temperature
+	sourceTemp    variableIntegrator
+	sinkTemp      variableIntegrator
+	// the operating point, updated every step
+	on               bool
+	heatOutput       float64 // W
+	electricalPower  float64 // W
+	electricalEnergy float64 // J; total over the run
+	deliveredEnergy  float64 // J; total over the run
+	dataRecorder
+}
+
+func (hp *heatPump) getName() string {
+	return hp.name
+}
+
+func (hp *heatPump) update(time float64, timeStep float64) {
+	sourceTemp := hp.sourceTemp()
+	sinkTemp := hp.sinkTemp()
+	if hp.on && sinkTemp >= hp.setpoint {
+		hp.on = false
+	} else if !hp.on && sinkTemp < hp.setpoint-hp.deadband {
+		hp.on = true
+	}
+
+	// outside the operating envelope the compressor is locked out, but the thermostat still calls for heat
+	hp.heatOutput, hp.electricalPower = 0.0, 0.0
+	cop := 0.0
+	if hp.on && sourceTemp >= hp.minSourceTemp && sinkTemp < hp.maxSinkTemp {
+		cop = hp.cop.at(sourceTemp, sinkTemp)
+		hp.heatOutput = hp.ratedCapacity * hp.capacity.at(sourceTemp, sinkTemp)
+		// P = Q/COP
+		hp.electricalPower = hp.heatOutput / cop
+	}
+	hp.electricalEnergy += hp.electricalPower * timeStep
+	hp.deliveredEnergy += hp.heatOutput * timeStep
+
+	hp.addDataPoint("Heat Output", hp.heatOutput)
+	hp.addDataPoint("Electrical Power", hp.electricalPower)
+	hp.addDataPoint("COP", cop)
+}
+
+// getHeat is the heat delivered to the tank during the current step
+func (hp *heatPump) getHeat() float64 {
+	return hp.heatOutput
+}
+
+// getSourceHeat is the heat taken from the source during the current step: Qₛ = Q - P
+func (hp *heatPump) getSourceHeat() float64 {
+	return hp.heatOutput - hp.electricalPower
+}
+
+func (hp *heatPump) getState() map[string]float64 {
+	on := 0.0
+	if hp.on {
+		on = 1.0
+	}
+	return map[string]float64{
+		"on":               on,
+		"electricalEnergy": hp.electricalEnergy,
+		"deliveredEnergy":  hp.deliveredEnergy,
+	}
+}
+
+func (hp *heatPump) setState(state map[string]float64) error {
+	hp.on = state["on"] != 0
+	hp.electricalEnergy = state["electricalEnergy"]
+	hp.deliveredEnergy = state["deliveredEnergy"]
+	return nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPerformanceMap(t *testing.T) {
+	m := performanceMap{
+		sourceTemps: []float64{0, 20},
+		sinkTemps:   []float64{20, 60},
+		values: [][]float64{
+			{3, 1},
+			{5, 3},
+		},
+	}
+	tests := []struct {
+		name       string
+		sourceTemp float64
+		sinkTemp   float64
+		expected   float64
+	}{
+		{name: "Grid point", sourceTemp: 20, sinkTemp: 20, expected: 5},
+		{name: "Center", sourceTemp: 10, sinkTemp: 40, expected: 3},
+		{name: "Edge", sourceTemp: 10, sinkTemp: 60, expected: 2},
+		{name: "Held outside the map", sourceTemp: -20, sinkTemp: 80, expected: 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if value := m.at(tt.sourceTemp, tt.sinkTemp); math.Abs(value-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected %v, got %v", tt.expected, value)
+			}
+		})
+	}
+}
+
+func TestLoadPerformanceMapCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "cop.csv")
+	if err := os.WriteFile(fileName, []byte("source,20,60\n0,3,1\n20,5,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := loadPerformanceMapCSV(fileName, positiveCOP)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if value := m.at(10, 40); math.Abs(value-3) > float64EqualityThreshold {
+		t.Errorf("expected 3, got %v", value)
+	}
+
+	if err := os.WriteFile(fileName, []byte("source,20,60\n20,3,1\n0,5,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPerformanceMapCSV(fileName, positiveCOP); err == nil {
+		t.Error("expected error for decreasing source temperatures")
+	}
+
+	// a COP of 0 would divide the heat output by zero, while a capacity of 0 just stops the heating
+	if err := os.WriteFile(fileName, []byte("source,20,60\n0,3,0\n20,5,3\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPerformanceMapCSV(fileName, positiveCOP); err == nil {
+		t.Error("expected error for a COP of 0")
+	}
+	if _, err := loadPerformanceMapCSV(fileName, nonNegativeCapacity); err != nil {
+		t.Errorf("expected a capacity of 0 to load, got %v", err)
+	}
+	if err := os.WriteFile(fileName, []byte("source,20,60\n0,1,-0.1\n20,1,1\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadPerformanceMapCSV(fileName, nonNegativeCapacity); err == nil {
+		t.Error("expected error for a negative capacity")
+	}
+}
+
+func TestHeatPump(t *testing.T) {
+	sinkTemp := 40.0
+	sourceTemp := 15.0
+	hp := heatPump{
+		name:          "HeatPump",
+		ratedCapacity: 1000,
+		cop:           performanceMap{sourceTemps: []float64{0}, sinkTemps: []float64{0}, values: [][]float64{{2.5}}},
+		capacity:      performanceMap{sourceTemps: []float64{0}, sinkTemps: []float64{0}, values: [][]float64{{1.2}}},
+		setpoint:      55,
+		deadband:      5,
+		maxSinkTemp:   65,
+		minSourceTemp: -7,
+		sourceTemp:    func() float64 { return sourceTemp },
+		sinkTemp:      func() float64 { return sinkTemp },
+	}
+
+	hp.update(0, 10)
+	if hp.getHeat() != 1200 || hp.electricalPower != 480 {
+		t.Errorf("expected 1200 W heat for 480 W electricity, got %v W and %v W", hp.getHeat(), hp.electricalPower)
+	}
+	if hp.getSourceHeat() != 720 {
+		t.Errorf("expected 720 W from the source, got %v W", hp.getSourceHeat())
+	}
+	if hp.electricalEnergy != 4800 || hp.deliveredEnergy != 12000 {
+		t.Errorf("unexpected energy totals %v J and %v J", hp.electricalEnergy, hp.deliveredEnergy)
+	}
+
+	// the thermostat keeps running within the deadband, and stops at the setpoint
+	sinkTemp = 52
+	hp.update(10, 10)
+	if !hp.on {
+		t.Error("expected the heat pump to keep running within the deadband")
+	}
+	sinkTemp = 55
+	hp.update(20, 10)
+	if hp.on || hp.getHeat() != 0 {
+		t.Error("expected the heat pump to stop at the setpoint")
+	}
+	sinkTemp = 52
+	hp.update(30, 10)
+	if hp.on {
+		t.Error("expected the heat pump to stay off within the deadband")
+	}
+
+	// a source colder than the operating envelope locks out the compressor
+	sinkTemp = 40
+	sourceTemp = -10
+	hp.update(40, 10)
+	if !hp.on || hp.getHeat() != 0 || hp.electricalPower != 0 {
+		t.Errorf("expected no heat or electricity below the minimum source temperature, got %v W and %v W", hp.getHeat(), hp.electricalPower)
+	}
+}
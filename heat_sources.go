@@ -0,0 +1,102 @@
+// heat sources: a tank's stored heat is tracked by where it came from, so the heat the tank delivers can be split
+// into solar heat, auxiliary heat, and the heat it started with. The tank is treated as well mixed: its losses,
+// draws and loads take the same share of every source.
+package main
+
+import "math"
+
+// collectorSeries are the tank series that exchange heat with the collector loop, with the direction they carry it
+var collectorSeries = map[string]float64{
+	"Heat Input":           1,  // from the panels, directly or through a heat exchanger
+	"Collector Inflow":     1,  // the return from an array
+	"Heat Exchanger Input": 1,  // from an array through a heat exchanger
+	"Heat Output":          -1, // to the panels, when they exchange fluid with the tank directly
+}
+
+// tankHeatSources splits a tank's heat above referenceTemp by source
+type tankHeatSources struct {
+	tank            *fluidSystem
+	auxiliaryGain   variableIntegrator // W; optional; from a heat pump or other backup heater
+	upstream        *tankHeatSources   // optional; the tank the draw's inflow comes from
+	solarEnergy     float64            // J
+	auxiliaryEnergy float64            // J
+	otherEnergy     float64            // J; the starting heat, and any heat gained from the surroundings
+}
+
+func newTankHeatSources(tank *fluidSystem, referenceTemp float64) *tankHeatSources {
+	return &tankHeatSources{
+		tank:        tank,
+		otherEnergy: math.Max(0, tank.getHeatCapacity()*(tank.temperature-referenceTemp)),
+	}
+}
+
+// getShares is the fraction of the tank's heat from each source; an empty tank only holds other heat
+func (s *tankHeatSources) getShares() (solar float64, auxiliary float64, other float64) {
+	total := s.solarEnergy + s.auxiliaryEnergy + s.otherEnergy
+	if total <= 0 {
+		return 0.0, 0.0, 1.0
+	}
+	return s.solarEnergy / total, s.auxiliaryEnergy / total, s.otherEnergy / total
+}
+
+// getSolarShare is the fraction of the tank's heat that came from the collector
+func (s *tankHeatSources) getSolarShare() float64 {
+	solar, _, _ := s.getShares()
+	return solar
+}
+
+// update adds the tank's gains during the step to their sources, and takes whatever else the tank lost from
+// every source alike. It runs before the tank commits, while the step's heat is recorded.
+func (s *tankHeatSources) update(timeStep float64) {
+	solar := 0.0
+	for name, direction := range collectorSeries {
+		solar += direction * s.tank.getStepHeat(name)
+	}
+	auxiliary, upstream := 0.0, 0.0
+	if s.auxiliaryGain != nil {
+		auxiliary = s.auxiliaryGain()
+	}
+	if s.upstream != nil {
+		upstream = s.tank.getStepHeat("Draw Inflow")
+	}
+	// whatever isn't one of the sources is lost to the surroundings, the draw or the load, or gained from the surroundings
+	other := s.tank.getNetHeat() - solar - auxiliary - upstream
+
+	removed := 0.0
+	for _, gain := range []*float64{&solar, &upstream, &other} {
+		if *gain < 0 {
+			removed -= *gain
+			*gain = 0.0
+		}
+	}
+	s.solarEnergy += solar * timeStep
+	s.auxiliaryEnergy += auxiliary * timeStep
+	s.otherEnergy += other * timeStep
+	if upstream > 0 {
+		// the upstream tank's water brings its heat in that tank's proportions
+		solarShare, auxiliaryShare, otherShare := s.upstream.getShares()
+		s.solarEnergy += solarShare * upstream * timeStep
+		s.auxiliaryEnergy += auxiliaryShare * upstream * timeStep
+		s.otherEnergy += otherShare * upstream * timeStep
+	}
+	if total := s.solarEnergy + s.auxiliaryEnergy + s.otherEnergy; total > 0 {
+		remaining := math.Max(0, 1-removed*timeStep/total)
+		s.solarEnergy *= remaining
+		s.auxiliaryEnergy *= remaining
+		s.otherEnergy *= remaining
+	}
+}
+
+func (s *tankHeatSources) getState() map[string]float64 {
+	return map[string]float64{
+		"solarEnergy":     s.solarEnergy,
+		"auxiliaryEnergy": s.auxiliaryEnergy,
+		"otherEnergy":     s.otherEnergy,
+	}
+}
+
+func (s *tankHeatSources) setState(state map[string]float64) {
+	s.solarEnergy = state["solarEnergy"]
+	s.auxiliaryEnergy = state["auxiliaryEnergy"]
+	s.otherEnergy = state["otherEnergy"]
+}
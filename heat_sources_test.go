@@ -0,0 +1,38 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestTankHeatSources(t *testing.T) {
+	water := fluids[waterFluidName]
+	preheat := &fluidSystem{name: "PreheatTank", fluid: water, fluidMass: 100, temperature: 20}
+	tank := &fluidSystem{name: "StorageTank", fluid: water, fluidMass: 100, temperature: 30}
+	upstream := newTankHeatSources(preheat, 20)
+	sources := newTankHeatSources(tank, 20)
+	sources.upstream = upstream
+	if upstream.otherEnergy != 0 || math.Abs(sources.otherEnergy-tank.getHeatCapacity()*10) > 1e-6 {
+		t.Fatalf("expected only the heat above 20 °C to be counted, got %v J and %v J", upstream.otherEnergy, sources.otherEnergy)
+	}
+
+	// the preheat tank only gains solar heat
+	upstream.solarEnergy = 100000
+	// a step where the collector brings 1 kW, the preheat tank's water 1 kW, and the tank loses 1 kW
+	tank.reset()
+	tank.addStepDataPoint("Heat Input", 1000)
+	tank.addStepDataPoint("Draw Inflow", 1000)
+	tank.stepHeatIn = []float64{1000, 1000}
+	tank.stepHeatOut = []float64{1000}
+	initial := sources.otherEnergy
+	sources.update(10)
+
+	total := initial + 10000
+	remaining := 1 - 10000/(initial+20000)
+	if math.Abs(sources.solarEnergy-20000*remaining) > 1e-6 || math.Abs(sources.otherEnergy-initial*remaining) > 1e-6 {
+		t.Errorf("expected the loss to take its share of every source, got %v J solar and %v J other", sources.solarEnergy, sources.otherEnergy)
+	}
+	if stored := sources.solarEnergy + sources.otherEnergy; math.Abs(stored-total) > 1e-6 {
+		t.Errorf("expected %v J stored, got %v J", total, stored)
+	}
+}
@@ -0,0 +1,61 @@
+// hydraulic resistances give the pressure drop of loop elements as a function of volume flow,
+// so the pump's operating point can be solved every step
+package main
+
+import "math"
+
+type IHydraulicResistance interface {
+	getName() string
+	getPressureDrop(flowVolume float64, f *fluid, temp float64) float64 // flowVolume in m^3/s, returns Pa
+}
+
+// pipeResistance is a straight pipe with fittings, using Darcy-Weisbach: Δp = (fL/D + ΣK)·ρv²/2
+type pipeResistance struct {
+	component
+	length               float64 // m
+	diameter             float64 // m; inner diameter
+	roughness            float64 // m
+	minorLossCoefficient float64 // ΣK for bends, valves and fittings
+}
+
+func (r pipeResistance) getPressureDrop(flowVolume float64, f *fluid, temp float64) float64 {
+	if flowVolume <= 0 {
+		return 0.0
+	}
+	density := f.getDensity(temp)
+	velocity := flowVolume / (math.Pi * r.diameter * r.diameter / 4)
+	reynolds := density * velocity * r.diameter / f.getViscosity(temp)
+	friction := darcyFrictionFactor(reynolds, r.roughness/r.diameter)
+	return (friction*r.length/r.diameter + r.minorLossCoefficient) * density * velocity * velocity / 2
+}
+
+// darcyFrictionFactor is 64/Re for laminar flow, and the Swamee-Jain approximation of Colebrook for turbulent flow
+func darcyFrictionFactor(reynolds float64, relativeRoughness float64) float64 {
+	if reynolds < 2300 {
+		return 64 / reynolds
+	}
+	return 0.25 / math.Pow(math.Log10(relativeRoughness/3.7+5.74/math.Pow(reynolds, 0.9)), 2)
+}
+
+// quadraticResistance is an element rated by its pressure drop at one flow, e.g. a collector or heat exchanger: Δp = kQ²
+type quadraticResistance struct {
+	component
+	ratedPressureDrop float64 // Pa
+	ratedFlowVolume   float64 // m^3/s
+}
+
+func (r quadraticResistance) getPressureDrop(flowVolume float64, f *fluid, temp float64) float64 {
+	if flowVolume <= 0 || r.ratedFlowVolume <= 0 {
+		return 0.0
+	}
+	return r.ratedPressureDrop * math.Pow(flowVolume/r.ratedFlowVolume, 2)
+}
+
+// loopPressureDrop is the pressure drop of elements in series
+func loopPressureDrop(loop []IHydraulicResistance, flowVolume float64, f *fluid, temp float64) float64 {
+	pressureDrop := 0.0
+	for _, resistance := range loop {
+		pressureDrop += resistance.getPressureDrop(flowVolume, f, temp)
+	}
+	return pressureDrop
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"context"
 	"fmt"
 	"math"
 	"os"
+	"os/signal"
+	"sort"
+	"strings"
 
 	"github.com/go-echarts/go-echarts/v2/charts"
 	"github.com/go-echarts/go-echarts/v2/opts"
@@ -17,93 +21,854 @@ const (
 func main() {
 	config := initializeConfig()
 
-	sp := solarPanel{
-		fluidSystem: fluidSystem{
-			name: "SolarPanel",
-			// for simplicity, we'll ignore panel depth and treat the panel as if it is mounted on the roof
-			exposedSurfaceArea: config.panelSize,
-			ambientTemp:        config.outdoorAmbientTemp,
-			ambientHTC:         config.outdoorHTC,
-			fluidMass:          config.panelFluidMass,
-			temperature:        config.panelTemp,
-		},
-		panelArea:       config.panelSize,
-		panelEfficiency: config.panelEfficiency,
-		solarIrradiance: config.solarIrradiance,
-	}
-
-	st := storageTank{
-		fluidSystem: fluidSystem{
-			name: "StorageTank",
-			// for simplicty, tank dimensions aren't configurable
-			// tank dimensions: 1.7m tall, 0.3m radius
-			// A = 2πrh + πr^2 , where the side touching the ground is insulated
-			exposedSurfaceArea: 2*math.Pi*0.3*1.7 + math.Pi*math.Pow(0.3, 2),
-			ambientTemp:        config.indoorAmbientTemp,
-			ambientHTC:         config.indoorHTC,
-			fluidMass:          config.tankFluidMass,
-			temperature:        config.tankTemp,
-		},
+	timeStep := config.timeStep
+	if timeStep <= 0 {
+		timeStep = 1.0
+		if config.seasonalStorage != "" {
+			timeStep = 60 * 60
+		}
+	}
+	sim := simulation{
+		timeStep:           timeStep, // seconds
+		duration:           config.durationHours * 60 * 60,
+		checkpointInterval: config.checkpointHours * 60 * 60,
+		checkpointFile:     config.checkpointFile,
+	}
+	hourOfDay := func() float64 { return math.Mod(config.startHour+sim.currentTime/(60*60), 24) }
+	sunAt := func() sunPosition {
+		hours := config.startHour + sim.currentTime/(60*60)
+		return getSunPosition(config.startDay+math.Floor(hours/24), math.Mod(hours, 24), config.latitude)
+	}
+
+	windSpeed := func() float64 { return config.windSpeed }
+	if config.windSchedule != "" {
+		windSchedule, err := loadScheduleCSV(config.windSchedule)
+		if err != nil {
+			fatal("could not load wind schedule: %v", err)
+		}
+		windSpeed = func() float64 { return windSchedule.at(sim.currentTime) }
+	}
+	precipitation := func() float64 { return config.precipitation }
+	if config.precipitationSchedule != "" {
+		precipitationSchedule, err := loadScheduleCSV(config.precipitationSchedule)
+		if err != nil {
+			fatal("could not load precipitation schedule: %v", err)
+		}
+		precipitation = func() float64 { return precipitationSchedule.at(sim.currentTime) }
+	}
+	surfaceConditions := config.precipitation > 0 || config.precipitationSchedule != "" || config.snowDepth > 0 || config.soilingRate > 0
+	// the panel's characteristic length assumes a square panel
+	panelConvection, err := newConvectionCorrelation(config.convectionModel, windSpeed, config.panelTilt, math.Sqrt(config.panelSize))
+	if err != nil {
+		fatal("%v", err)
+	}
+
+	panelFluid, err := getFluid(config.panelFluid)
+	if err != nil {
+		fatal("PANEL_FLUID: %v", err)
+	}
+	tankFluid, err := getFluid(config.tankFluid)
+	if err != nil {
+		fatal("TANK_FLUID: %v", err)
+	}
+
+	if config.absorberNode && config.panelDryMass <= 0 {
+		fatal("PANEL_ABSORBER_NODE requires a PANEL_DRY_MASS")
+	}
+	if config.drainBack && (config.absorberNode || config.panelDryMass <= 0 && config.collectorType != evacuatedTubeCollector) {
+		fatal("DRAIN_BACK requires a PANEL_DRY_MASS for the drained collectors, without PANEL_ABSORBER_NODE")
+	}
+	var shading *skyShading
+	shadedSkyViewFactor := 1.0
+	if config.horizonFile != "" || len(config.obstructions) > 0 {
+		if config.beamIrradiance <= 0 && config.diffuseIrradiance <= 0 {
+			fatal("HORIZON_FILE and OBSTRUCTIONS require BEAM_IRRADIANCE or DIFFUSE_IRRADIANCE")
+		}
+		shading = &skyShading{obstructions: config.obstructions}
+		if config.horizonFile != "" {
+			if shading.horizon, err = loadHorizonCSV(config.horizonFile); err != nil {
+				fatal("could not load horizon profile: %v", err)
+			}
+		}
+		shadedSkyViewFactor = shading.skyViewFactor(config.panelTilt, config.panelAzimuth)
+		fmt.Printf("Shading leaves %.1f%% of the sky's diffuse radiation\n", shadedSkyViewFactor*100)
+	}
+	var tubes *evacuatedTubes
+	if config.collectorType == evacuatedTubeCollector {
+		if config.absorberNode {
+			fatal("PANEL_ABSORBER_NODE is not supported for evacuated tube collectors")
+		}
+		tubes = &evacuatedTubes{iam: defaultIncidenceAngleModifier, a1: config.etcLossA1, a2: config.etcLossA2}
+		if config.etcIAMFile != "" {
+			if tubes.iam, err = loadIncidenceAngleModifierCSV(config.etcIAMFile); err != nil {
+				fatal("could not load incidence angle modifiers: %v", err)
+			}
+		}
+	}
+	// newPanel creates a panel, with its absorber node if configured; the suffix tells apart the panels of an array
+	newPanel := func(suffix string) *solarPanel {
+		sp := &solarPanel{
+			fluidSystem: fluidSystem{
+				name: "SolarPanel" + suffix,
+				// for simplicity, we'll ignore panel depth and treat the panel as if it is mounted on the roof
+				exposedSurfaceArea: config.panelSize,
+				ambientTemp:        config.outdoorAmbientTemp,
+				ambientHTC:         config.outdoorHTC,
+				convection:         panelConvection,
+				fluidMass:          config.panelFluidMass,
+				fluid:              panelFluid,
+				temperature:        config.panelTemp,
+			},
+			panelArea:       config.panelSize,
+			panelEfficiency: config.panelEfficiency,
+			solarIrradiance: config.solarIrradiance,
+			tilt:            config.panelTilt,
+			tubes:           tubes,
+		}
+		if surfaceConditions {
+			sp.surface = &surfaceCondition{
+				name:          "SurfaceCondition" + suffix,
+				tilt:          config.panelTilt,
+				surfaceArea:   config.panelSize,
+				precipitation: precipitation,
+				airTemp:       func() float64 { return config.outdoorAmbientTemp },
+				soilingRate:   config.soilingRate / 100,
+				maxSoiling:    config.maxSoiling / 100,
+				cleaningRain:  config.cleaningRain,
+				snowMass:      config.snowDepth,
+				snowCover:     math.Min(1, config.snowDepth/fullSnowCover),
+			}
+		}
+		if config.beamIrradiance > 0 || config.diffuseIrradiance > 0 {
+			// the sun moves through the run: G = G_bn·cos θ + G_dh(1 + cos β)/2
+			sp.incidence = func() incidenceAngles { return getIncidenceAngles(sunAt(), config.panelTilt, config.panelAzimuth) }
+			sp.beamIrradiance = func() float64 {
+				if sunAt().altitude <= 0 {
+					return 0.0
+				}
+				return config.beamIrradiance * math.Max(0, math.Cos(sp.incidence().angle*degreesToRadians))
+			}
+			sp.diffuseIrradiance = func() float64 { return config.diffuseIrradiance * tiltedSkyViewFactor(config.panelTilt) }
+			if shading != nil {
+				// the horizon and obstructions block the beam behind them, and part of the sky
+				beamIrradiance := sp.beamIrradiance
+				sp.beamIrradiance = func() float64 {
+					if shading.isShaded(sunAt()) {
+						return 0.0
+					}
+					return beamIrradiance()
+				}
+				diffuseIrradiance := sp.diffuseIrradiance
+				sp.diffuseIrradiance = func() float64 { return shadedSkyViewFactor * diffuseIrradiance() }
+			}
+		}
+		if config.collectorType == pvtCollector {
+			sp.pv = &pvCells{
+				referenceEfficiency: config.pvEfficiency,
+				tempCoefficient:     config.pvTempCoefficient / 100,
+				referenceTemp:       config.pvReferenceTemp,
+			}
+		}
+		if config.absorberNode {
+			sp.absorber = &absorberPlate{
+				fluidSystem: fluidSystem{
+					name:               "Absorber" + suffix,
+					exposedSurfaceArea: config.panelSize,
+					ambientTemp:        config.outdoorAmbientTemp,
+					ambientHTC:         config.outdoorHTC,
+					convection:         panelConvection,
+					dryHeatCapacity:    config.panelDryMass * config.panelDryCp,
+					temperature:        config.panelTemp,
+				},
+				panelArea:       config.panelSize,
+				panelEfficiency: config.panelEfficiency,
+				fluidHTC:        config.absorberFluidHTC,
+			}
+		} else if tubes != nil {
+			sp.dryHeatCapacity = config.etcHeatCapacity * 1000 * config.panelSize
+		} else {
+			sp.dryHeatCapacity = config.panelDryMass * config.panelDryCp
+		}
+		return sp
+	}
+
+	// the tanks' room, warmed by their losses, instead of a constant indoor temperature
+	var zone *thermalZone
+	if config.indoorZone {
+		zone = &thermalZone{
+			fluidSystem: fluidSystem{
+				name:            "IndoorZone",
+				dryHeatCapacity: config.zoneVolume*airVolumetricHeatCapacity + config.zoneThermalMass*1000,
+				temperature:     config.indoorAmbientTemp,
+			},
+			envelopeUA:    config.zoneUA,
+			internalGains: func() float64 { return config.zoneGains },
+			outdoorTemp:   func() float64 { return config.outdoorAmbientTemp },
+		}
+		zone.initialize()
+	}
+
+	// buried tanks and pipes lose heat to the soil, optionally through a ring of soil warmed by their losses
+	soil := kusudaGround{
+		meanTemp:        config.groundMeanTemp,
+		annualAmplitude: config.groundAnnualAmplitude,
+		dailyAmplitude:  config.groundDailyAmplitude,
+		diffusivity:     config.groundDiffusivity / (24 * 60 * 60),
+		conductivity:    config.groundConductivity,
+		coldestDay:      config.groundColdestDay,
+		coldestHour:     5, // the surface is coldest around dawn
+	}
+	dayOfYear := func() float64 { return config.startDay + (config.startHour+sim.currentTime/(60*60))/24 }
+	if config.seasonalStorage != "" {
+		// a seasonal storage run replaces the collector loop and tanks with a collector field charging a pit
+		runSeasonalStorage(config, &sim, soil, dayOfYear, sunAt)
+		return
+	}
+	soilRings := []*fluidSystem{}
+	buriedAmbient := func(name string, depth float64, radius float64, length float64) IFluidSystem {
+		ground := &undisturbedGround{name: "Ground", depth: depth, soil: soil, day: dayOfYear}
+		if config.soilRingRadius <= 0 {
+			return ground
+		}
+		if config.soilRingRadius <= radius || config.soilRingNodes < 1 {
+			fatal("SOIL_RING_RADIUS must be larger than the buried element, with at least one SOIL_RING_NODES")
+		}
+		ring, err := newSoilRing(name, radius, config.soilRingRadius, length, config.soilRingNodes, ground, sim.timeStep)
+		if err != nil {
+			fatal("soil ring: %v; use fewer SOIL_RING_NODES or a shorter TIME_STEP", err)
+		}
+		soilRings = append(soilRings, ring...)
+		return ring[0]
+	}
+	if config.tankBurialDepth > 0 && config.indoorZone {
+		fatal("TANK_BURIAL_DEPTH and INDOOR_ZONE can't both be the tanks' ambient")
+	}
+
+	// both tanks share the same geometry; the UA is the insulated U-value over the surfaces that lose heat
+	geometry := tankGeometry{
+		height:                 config.tankHeight,
+		diameter:               config.tankDiameter,
+		orientation:            config.tankOrientation,
+		insulationThickness:    config.tankInsulationThickness,
+		insulationConductivity: config.tankInsulationK,
+		topLoss:                config.tankTopLoss,
+		sideLoss:               config.tankSideLoss,
+		bottomLoss:             config.tankBottomLoss,
+	}
+	if size, ok := tankSizes[config.tankSize]; ok {
+		geometry.height, geometry.diameter = size.height, size.diameter
+	}
+	if geometry.height <= 0 || geometry.diameter <= 0 {
+		fatal("TANK_HEIGHT and TANK_DIAMETER must be positive")
+	}
+	if geometry.insulationThickness > 0 && geometry.insulationConductivity <= 0 {
+		fatal("TANK_INSULATION_CONDUCTIVITY must be positive")
+	}
+	newTank := func(name string, massSetting string, fluidMass float64, temp float64) *storageTank {
+		fullMass := geometry.getVolume() * tankFluid.getDensity(temp)
+		if fluidMass <= 0 {
+			// the tank is full
+			fluidMass = fullMass
+		}
+		if fluidMass > fullMass {
+			fatal("%s holds at most %.1f kg of %s, so %s=%g doesn't fit; use 0 to fill it", name, fullMass, tankFluid.name, massSetting, fluidMass)
+		}
+		tank := &storageTank{
+			fluidSystem: fluidSystem{
+				name:               name,
+				exposedSurfaceArea: geometry.getLossArea(),
+				ambientTemp:        config.indoorAmbientTemp,
+				ambientHTC:         geometry.getUValue(config.indoorHTC),
+				fluidMass:          fluidMass,
+				fluid:              tankFluid,
+				temperature:        temp,
+			},
+		}
+		if zone != nil {
+			tank.ambient = zone
+		}
+		if config.tankBurialDepth > 0 {
+			// the tank's middle is at the burial depth
+			tank.ambient = buriedAmbient(name, config.tankBurialDepth, geometry.getRadius(), geometry.height)
+			tank.ambientHTC = geometry.getUValue(config.buriedTankHTC)
+		}
+		return tank
+	}
+	st := newTank("StorageTank", "TANK_WATER_MASS", config.tankFluidMass, config.tankTemp)
+	// tanks are in draw order, from the mains to the load; the collector charges chargedTanks
+	tanks := []*storageTank{st}
+	chargedTanks := []*storageTank{st}
+	if config.tankLayout != singleTankLayout {
+		preheatTank := newTank("PreheatTank", "PREHEAT_TANK_WATER_MASS", config.preheatTankFluidMass, config.preheatTankTemp)
+		tanks = []*storageTank{preheatTank, st}
+		chargedTanks = []*storageTank{preheatTank}
+		if config.tankLayout == diverterTankLayout {
+			chargedTanks = tanks
+		}
+	}
+
+	// the pump circulates the collector loop; its flow is solved against the loop's pressure drop every step
+	ratedFlowVolume := config.pumpFlowRate / panelFluid.getDensity(config.panelTemp)
+	collectorResistance := quadraticResistance{component{"Collector"}, config.collectorPressureDrop * 1000, ratedFlowVolume}
+	collectorLoop := []IHydraulicResistance{
+		pipeResistance{component{"Pipe"}, config.pipeLength, config.pipeDiameter, config.pipeRoughness, config.pipeFittingsK},
+	}
+	if config.heatExchanger != "" {
+		collectorLoop = append(collectorLoop, quadraticResistance{component{"Heat Exchanger"}, config.heatExchangerPressureDrop * 1000, ratedFlowVolume})
+	}
+	if config.checkValvePressure > 0 {
+		collectorLoop = append(collectorLoop, checkValve{component{"Check Valve"}, config.checkValvePressure * 1000})
+	}
+	collectorPump := &pump{
+		name:          "Pump",
+		model:         config.pumpModel,
+		ratedFlowMass: config.pumpFlowRate,
+		shutoffHead:   config.pumpShutoffHead,
+		maxFlowVolume: config.pumpMaxFlow / (60 * 60),
+		efficiency:    config.pumpEfficiency,
+		signalType:    config.pumpSignalType,
+		signal:        func() float64 { return config.pumpSignal },
+		fluid:         panelFluid,
+	}
+	collectorFlow := collectorPump.getFlowMass
+	var drain *drainBackSystem
+	if config.drainBack {
+		// the collector fluid circulates once it has been lifted from the reservoir and fills the collectors
+		drain = &drainBackSystem{
+			name:      "DrainBack",
+			fluidMass: config.panelFluidMass,
+			reservoir: &fluidSystem{
+				name:               "DrainBackReservoir",
+				exposedSurfaceArea: config.reservoirSurfaceArea,
+				ambientTemp:        config.indoorAmbientTemp,
+				ambientHTC:         config.reservoirHTC,
+				fluidMass:          config.reservoirFluidMass,
+				fluid:              panelFluid,
+				temperature:        config.indoorAmbientTemp,
+				heatInComponents:   []IComponent{},
+			},
+			pump:     collectorPump,
+			fillTime: config.drainBackFillTime,
+			liftHead: config.drainBackLift,
+		}
+		if zone != nil {
+			drain.reservoir.ambient = zone
+		}
+		drain.reservoir.addEnvironmentalConvectionHeatLossComponent()
+		collectorPump.staticHead = drain.getStaticHead
+		collectorFlow = drain.getFlowMass
+	}
+	// with a diverter, each charged tank gets the collector's flow while the valve is turned to it
+	diverter := &diverterValve{component{"Diverter"}, collectorFlow, 0}
+	tankFlows := []variableIntegrator{collectorFlow}
+	if len(chargedTanks) > 1 {
+		tankFlows = []variableIntegrator{diverter.getOutletFlow(0), diverter.getOutletFlow(1)}
+	}
+	// a tank's heat exchanger loop pump runs whenever the collector loop flows to it
+	tankLoopFlows := make([]variableIntegrator, len(tankFlows))
+	for i, tankFlow := range tankFlows {
+		tankLoopFlows[i] = func() float64 {
+			if tankFlow() > 0 {
+				return config.tankLoopFlowRate
+			}
+			return 0.0
+		}
+	}
+
+	heatExchangerUA := config.heatExchangerUA
+	if heatExchangerUA == 0 {
+		switch config.heatExchanger {
+		case immersedCoilHeatExchanger:
+			innerHTC := tubeInnerHTC(panelFluid, config.panelTemp, config.pumpFlowRate, config.coilDiameter)
+			heatExchangerUA = coilUA(config.coilLength, config.coilDiameter, innerHTC, config.coilOuterHTC)
+		case plateHeatExchanger:
+			heatExchangerUA = plateHeatExchangerUA(config.plateCount, config.plateArea, config.plateHTC)
+		}
 	}
 
 	// initialize the systems: hook up system outputs and inputs
-	sp.initialize([]IFluidSystem{&st.fluidSystem}, config.pumpFlowRate)
-	st.initialize([]IFluidSystem{&sp.fluidSystem}, config.pumpFlowRate)
+	var panels []*solarPanel
+	var array *collectorArray
+	collectorTemp := func() float64 { return panels[0].temperature }
+	if config.arrayBranches*config.arraySeries > 1 {
+		// an array's panels are connected in series and parallel, and return to the tank through one outlet
+		array = &collectorArray{
+			name:         "CollectorArray",
+			returnLayout: config.arrayReturn,
+			collector:    collectorResistance,
+			header:       pipeResistance{component{"Header"}, config.arrayHeaderLength, config.arrayHeaderDiameter, config.pipeRoughness, 0},
+			fluid:        panelFluid,
+			flowMass:     collectorFlow,
+		}
+		array.build(config.arrayBranches, config.arraySeries, newPanel)
+		panels = array.getPanels()
+		for _, sp := range panels {
+			sp.initialize(nil, collectorFlow)
+		}
+		for _, tank := range tanks {
+			tank.initialize(nil, nil)
+		}
+		arrangement := config.heatExchanger
+		if arrangement == plateHeatExchanger {
+			arrangement = counterflowHeatExchanger
+		}
+		returnTemps := make([]variableIntegrator, len(chargedTanks))
+		for i, tank := range chargedTanks {
+			returnTemps[i] = array.connectTank(&tank.fluidSystem, tankFlows[i], arrangement, heatExchangerUA, tankLoopFlows[i])
+		}
+		inletTemp := returnTemps[0]
+		if len(returnTemps) > 1 {
+			// the returns from both tanks join before the array
+			inletTemp = mixingValve{
+				component: component{"Return"},
+				fraction: func() float64 {
+					if collectorFlow() <= 0 {
+						return 1.0
+					}
+					return tankFlows[0]() / collectorFlow()
+				},
+				inletTempA: returnTemps[0],
+				inletTempB: returnTemps[1],
+			}.getOutletTemp
+		}
+		array.initialize(inletTemp)
+		// the pump's first step uses the split at the rated flow
+		array.splitFlow(config.pumpFlowRate)
+		collectorLoop = append(collectorLoop, array)
+		collectorTemp = array.getOutletTemp
+	} else {
+		sp := newPanel("")
+		panels = []*solarPanel{sp}
+		collectorLoop = append(collectorLoop, collectorResistance)
+		sp.initialize(nil, collectorFlow)
+		for _, tank := range tanks {
+			tank.initialize(nil, nil)
+		}
+		for i, tank := range chargedTanks {
+			switch config.heatExchanger {
+			case immersedCoilHeatExchanger:
+				// the collector loop passes through a coil in the tank, and doesn't mix with the tank's water
+				sp.addImmersedCoilComponent(&tank.fluidSystem, heatExchangerUA, tankFlows[i])
+			case plateHeatExchanger:
+				// the collector loop and a tank loop both flow through an external heat exchanger
+				sp.addPlateHeatExchangerComponent(&tank.fluidSystem, heatExchangerUA, tankFlows[i], tankLoopFlows[i],
+					func() float64 { return tank.getSpecificHeat() })
+			default:
+				sp.addOutputHeatFluidComponent(&tank.fluidSystem, tankFlows[i])
+				tank.addOutputHeatFluidComponent(&sp.fluidSystem, tankFlows[i])
+			}
+		}
+	}
+	if config.buriedPipeLength > 0 {
+		// the collector's supply line runs underground, cooling the fluid leaving the collector
+		pipeGround := buriedAmbient("SupplyPipe", config.pipeBurialDepth, config.pipeDiameter/2+config.pipeInsulationThickness, config.buriedPipeLength)
+		pipeUA := insulatedPipeConductance(config.pipeDiameter, config.pipeInsulationThickness, config.pipeInsulationK, config.buriedPipeLength)
+		outlets := []*solarPanel{panels[0]}
+		outletFlows := []variableIntegrator{collectorFlow}
+		if array != nil {
+			outlets, outletFlows = nil, nil
+			for b, branch := range array.branches {
+				outlets = append(outlets, branch[len(branch)-1])
+				outletFlows = append(outletFlows, func() float64 { return array.branchFlows[b] })
+			}
+		}
+		for i, sp := range outlets {
+			sp.heatOutComponents = append(sp.heatOutComponents, transferHeatComponentWrapper{
+				component: component{
+					name: "Buried Pipe Heat Loss",
+				},
+				wrappedComponent: buriedPipeLossComponent{
+					conductance:  pipeUA,
+					flowMass:     outletFlows[i],
+					pipeFlowMass: collectorFlow,
+					specificHeat: func() float64 { return sp.getSpecificHeat() },
+					currentTemp:  func() float64 { return sp.temperature },
+					groundTemp:   pipeGround.getTemp,
+				},
+				output: pipeGround,
+			})
+		}
+	}
+
+	if drain != nil {
+		drain.panels = panels
+		drain.initialize()
+	}
+
+	collectorPump.loop = collectorLoop
+	collectorPump.fluidTemp = collectorTemp
+	chargedTemp := func() float64 { return chargedTanks[0].temperature }
+	if len(chargedTanks) > 1 {
+		diverterControl := &diverterController{
+			name:          "DiverterController",
+			margin:        config.diverterDeltaT,
+			collectorTemp: collectorTemp,
+			valve:         diverter,
+		}
+		for _, tank := range chargedTanks {
+			diverterControl.tankTemps = append(diverterControl.tankTemps, func() float64 { return tank.temperature })
+		}
+		chargedTemp = diverterControl.getSelectedTemp
+		sim.controllers = append(sim.controllers, diverterControl)
+	}
+	if config.pumpControl == differentialPumpControl {
+		pumpController := &differentialController{
+			name:      "PumpController",
+			onDeltaT:  config.pumpOnDeltaT,
+			offDeltaT: config.pumpOffDeltaT,
+			onSignal:  config.pumpSignal,
+			hotTemp:   collectorTemp,
+			coldTemp:  chargedTemp,
+		}
+		collectorPump.signal = pumpController.getSignal
+		sim.controllers = append(sim.controllers, pumpController)
+	}
+	sim.controllers = append(sim.controllers, collectorPump)
+	if drain != nil {
+		sim.controllers = append(sim.controllers, drain)
+	}
+	if array != nil {
+		sim.controllers = append(sim.controllers, array)
+	}
+	for _, sp := range panels {
+		if sp.surface != nil {
+			sim.controllers = append(sim.controllers, sp.surface)
+		}
+	}
+	var draw *hotWaterDraw
+	if config.drawFlowRate > 0 || config.drawSchedule != "" {
+		draw = &hotWaterDraw{
+			name:       "Draw",
+			flowRate:   func() float64 { return config.drawFlowRate },
+			mainsTemp:  config.mainsTemp,
+			supplyTemp: func() float64 { return st.temperature },
+			fluid:      tankFluid,
+		}
+		if config.drawSchedule != "" {
+			drawSchedule, err := loadScheduleCSV(config.drawSchedule)
+			if err != nil {
+				fatal("could not load draw schedule: %v", err)
+			}
+			draw.flowRate = func() float64 { return drawSchedule.at(sim.currentTime) }
+		}
+		tankSystems := []*fluidSystem{}
+		for _, tank := range tanks {
+			tankSystems = append(tankSystems, &tank.fluidSystem)
+		}
+		draw.connect(tankSystems)
+		if config.mixingValveSetpoint > 0 {
+			draw.addMixingValve(config.mixingValveSetpoint)
+		}
+		sim.controllers = append(sim.controllers, draw)
+	}
 
-	systems := []ISystem{&sp, &st}
-	totalTime := config.durationHours * 60 * 60
-	simulationRuntime := int(totalTime)
+	var heating *spaceHeatingLoad
+	if config.spaceHeating != "" {
+		// the heating loop draws from the tank that supplies the load
+		heating = newSpaceHeatingLoad(config, func() float64 { return config.outdoorAmbientTemp }, func() float64 { return st.temperature }, tankFluid)
+		heating.connect(&st.fluidSystem)
+		sim.controllers = append(sim.controllers, heating)
+	}
 
-	// setup plot arrays
-	timeSeries := make([]float64, 0, simulationRuntime)
-	systemsSeries := make(map[string][]opts.LineData)
-	for _, sys := range systems {
-		systemsSeries[sys.getName()] = make([]opts.LineData, 0, simulationRuntime)
+	var hp *heatPump
+	if config.heatPumpEnabled {
+		// the heat pump heats the tank that supplies the load
+		hp = &heatPump{
+			name:          "HeatPump",
+			ratedCapacity: config.heatPumpCapacity,
+			cop:           defaultHeatPumpCOP,
+			capacity:      defaultHeatPumpCapacity,
+			setpoint:      config.heatPumpSetpoint,
+			deadband:      config.heatPumpDeadband,
+			maxSinkTemp:   config.heatPumpMaxTankTemp,
+			minSourceTemp: config.heatPumpMinSourceTemp,
+			sourceTemp:    func() float64 { return st.getAmbientTemp() },
+			sinkTemp:      func() float64 { return st.temperature },
+		}
+		if config.heatPumpSource == outdoorHeatPumpSource {
+			hp.sourceTemp = func() float64 { return config.outdoorAmbientTemp }
+		} else if config.tankBurialDepth > 0 {
+			// a buried tank's ambient is the soil, so the heat pump draws from the house instead
+			hp.sourceTemp = func() float64 { return config.indoorAmbientTemp }
+		}
+		if config.heatPumpCOPMap != "" {
+			if hp.cop, err = loadPerformanceMapCSV(config.heatPumpCOPMap, positiveCOP); err != nil {
+				fatal("could not load heat pump COP map: %v", err)
+			}
+		}
+		if config.heatPumpCapacityMap != "" {
+			if hp.capacity, err = loadPerformanceMapCSV(config.heatPumpCapacityMap, nonNegativeCapacity); err != nil {
+				fatal("could not load heat pump capacity map: %v", err)
+			}
+		}
+		st.heatInComponents = append(st.heatInComponents, hp)
+		if zone != nil && config.heatPumpSource == indoorHeatPumpSource {
+			// the heat pump cools the room it draws heat from
+			zone.heatOutComponents = append(zone.heatOutComponents, heatRateComponent{
+				component: component{
+					name: "Heat Pump Source",
+				},
+				heat: hp.getSourceHeat,
+			})
+		}
+		sim.controllers = append(sim.controllers, hp)
+	}
+	if heating != nil {
+		// the tanks' heat is tracked by source, so the heating's solar share leaves out the starting and auxiliary heat.
+		// Heat below the indoor temperature can't heat the building, so it isn't counted.
+		var upstream *tankHeatSources
+		for _, tank := range tanks {
+			tank.sources = newTankHeatSources(&tank.fluidSystem, config.heatingIndoorTemp)
+			tank.sources.upstream = upstream
+			upstream = tank.sources
+		}
+		if hp != nil {
+			st.sources.auxiliaryGain = hp.getHeat
+		}
+		heating.solarShare = st.sources.getSolarShare
 	}
 
-	// run the simulation
-	fmt.Println("Starting simulation...")
-	timeStep := 1.0 // seconds
-	for t := 0.0; t < totalTime+float64EqualityThreshold; t += timeStep {
-		timeSeries = append(timeSeries, t)
-		for _, sys := range systems {
-			sys.reset()
+	steadyStateSystems := []ISteadyStateSystem{}
+	systems := []ISystem{}
+	for _, sp := range panels {
+		// the panel's outer surface loses heat to the sky: either the panel itself, or its absorber node
+		panelSurface := &sp.fluidSystem
+		if sp.absorber != nil {
+			panelSurface = &sp.absorber.fluidSystem
 		}
-		for _, sys := range systems {
-			sys.step()
+		// an evacuated tube's loss coefficients already include its radiation loss
+		if config.panelEmissivity > 0 && sp.tubes == nil {
+			skyTemp := func() float64 { return swinbankSkyTemp(panelSurface.ambientTemp) }
+			if config.skyModel == berdahlMartinSkyModel {
+				skyTemp = func() float64 {
+					return berdahlMartinSkyTemp(panelSurface.ambientTemp, config.dewPoint, config.cloudCover, hourOfDay())
+				}
+			}
+			panelSurface.addRadiationHeatLossComponent(config.panelEmissivity, tiltedSkyViewFactor(config.panelTilt), skyTemp)
 		}
-		for _, sys := range systems {
-			sys.commit(timeStep)
-			systemsSeries[sys.getName()] = append(systemsSeries[sys.getName()], opts.LineData{Value: sys.getTemp()})
+
+		steadyStateSystems = append(steadyStateSystems, sp)
+		systems = append(systems, sp)
+	}
+	for _, tank := range tanks {
+		steadyStateSystems = append(steadyStateSystems, tank)
+		systems = append(systems, tank)
+	}
+	if drain != nil {
+		steadyStateSystems = append(steadyStateSystems, drain.reservoir)
+		systems = append(systems, drain.reservoir)
+	}
+	if zone != nil {
+		steadyStateSystems = append(steadyStateSystems, zone)
+		systems = append(systems, zone)
+	}
+	for _, node := range soilRings {
+		steadyStateSystems = append(steadyStateSystems, node)
+		systems = append(systems, node)
+	}
+	for _, sp := range panels {
+		if sp.absorber != nil {
+			steadyStateSystems = append(steadyStateSystems, sp.absorber)
+			systems = append(systems, sp.absorber)
 		}
 	}
 
+	if config.steadyState {
+		// the steady state holds the pump at its starting operating point
+		for _, controller := range sim.controllers {
+			controller.update(0, sim.timeStep)
+		}
+		printSteadyState(solveSteadyState(steadyStateSystems))
+		return
+	}
+
+	sim.systems = systems
+	prepareRun(&sim, config)
+	// an interrupt stops the run early but still plots the partial results
+	result := runWithProgress(&sim)
+	fmt.Printf("Pump electrical energy: %.3f kWh\n", collectorPump.electricalEnergy/(1000*60*60))
+	if drain != nil {
+		fmt.Printf("Drain-back: %d fills, %.3f kWh of pump energy filling\n", drain.fillCount, drain.fillEnergy/(1000*60*60))
+	}
+	if hp != nil && hp.electricalEnergy > 0 {
+		fmt.Printf("Heat pump: %.3f kWh delivered, %.3f kWh electricity, average COP %.2f\n",
+			hp.deliveredEnergy/(1000*60*60), hp.electricalEnergy/(1000*60*60), hp.deliveredEnergy/hp.electricalEnergy)
+	}
+	if draw != nil {
+		fmt.Printf("Hot water delivered: %.3f kWh\n", draw.deliveredEnergy/(1000*60*60))
+		if draw.tempering != nil {
+			fmt.Printf("Setpoint unmet for %s\n", formatSimulatedTime(draw.unmetDuration))
+		}
+	}
+	if config.collectorType == pvtCollector {
+		electricalEnergy := 0.0
+		for _, sp := range panels {
+			electricalEnergy += sp.pv.electricalEnergy
+		}
+		fmt.Printf("PV electrical output: %.3f kWh\n", electricalEnergy/(1000*60*60))
+	}
+	if surfaceConditions {
+		lostEnergy := 0.0
+		for _, sp := range panels {
+			lostEnergy += sp.surface.lostEnergy
+		}
+		fmt.Printf("Radiation lost to snow and soiling: %.3f kWh\n", lostEnergy/(1000*60*60))
+	}
+	if heating != nil {
+		fmt.Printf("Space heating: %.3f kWh demand, %.3f kWh from the tank, tank share %.1f%%, solar share %.1f%%\n",
+			heating.demandEnergy/(1000*60*60), heating.deliveredEnergy/(1000*60*60), heating.getTankShare()*100, heating.getSolarShare()*100)
+	}
+	if array != nil {
+		for b, branch := range array.branches {
+			fmt.Printf("Branch %d: %.4f kg/s, outlet %.2f °C\n", b+1, array.branchFlows[b], branch[len(branch)-1].temperature)
+		}
+	}
+	plotResults(&sim, result, "Solar Panel temperature vs. Storage Tank temperature over time")
+	fmt.Println("Complete.")
+}
+
+// prepareRun resumes from a checkpoint if configured, and adds the configured stop conditions and events
+func prepareRun(sim *simulation, config config) {
+	if config.resumeFile != "" {
+		cp, err := readCheckpoint(config.resumeFile)
+		if err == nil {
+			err = sim.restoreCheckpoint(cp)
+		}
+		if err != nil {
+			fatal("could not resume from %s: %v", config.resumeFile, err)
+		}
+		fmt.Printf("Resuming from %s at %s\n", config.resumeFile, formatSimulatedTime(cp.Time))
+	}
+	for _, threshold := range config.stopTemps {
+		sim.stopConditions = append(sim.stopConditions, &temperatureThresholdCondition{systemName: threshold.systemName, threshold: threshold.temp})
+	}
+	if config.stopSteadyRate > 0 {
+		sim.stopConditions = append(sim.stopConditions, steadyStateCondition{maxRate: config.stopSteadyRate})
+	}
+	if config.stopWallClock > 0 {
+		sim.stopConditions = append(sim.stopConditions, wallClockCondition{budget: config.stopWallClock})
+	}
+	for _, threshold := range config.eventTemps {
+		sim.events = append(sim.events, &temperatureThresholdCondition{systemName: threshold.systemName, threshold: threshold.temp})
+	}
+}
+
+// runWithProgress runs the simulation with a progress bar, and prints its events and warnings.
+// An interrupt stops the run early but still returns the partial results.
+func runWithProgress(sim *simulation) simulationResult {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	sim.onProgress = printProgressBar
+	fmt.Println("Starting simulation...")
+	result := sim.run(ctx)
+	stop()
+	fmt.Fprintln(os.Stderr)
+	for _, event := range result.events {
+		fmt.Println(event)
+	}
+	for _, warning := range result.warnings {
+		fmt.Println("Warning:", warning)
+	}
+	fmt.Printf("Simulation ended at %s: %s\n", formatSimulatedTime(result.stopTime), result.stopReason)
+	return result
+}
+
+// plotResults plots the systems' temperatures, and the data of each system and each controller that records data
+func plotResults(sim *simulation, result simulationResult, subtitle string) {
 	// plot the temperature results
 	line := charts.NewLine()
 	line.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
 		Title:    "Temperature",
-		Subtitle: "Solar Panel temperature vs. Storage Tank temperature over time",
+		Subtitle: subtitle,
 	}))
-	line.SetXAxis(timeSeries)
-	for name, series := range systemsSeries {
+	line.SetXAxis(result.timeSeries)
+	for name, series := range result.systemsSeries {
 		line.AddSeries(name, series)
 	}
 	plotLine(line, "TemperatureSeries.html")
 
-	// plot the results for each system's power values
-	for _, sys := range systems {
-		sysLine := charts.NewLine()
-		sysLine.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
-			Title: sys.getName(),
-		}))
-		sysLine.SetXAxis(timeSeries)
-		for name, sysSeries := range sys.getData() {
-			sysLine.AddSeries(name, *sysSeries)
+	// plot the results for each system's power values, and for each controller that records data.
+	// The data is recorded once per step, so it is plotted at the steps' ends.
+	stepEnds := result.timeSeries[1:]
+	for _, sys := range sim.systems {
+		plotData(sys.getName(), stepEnds, sys.getData())
+	}
+	for _, controller := range sim.controllers {
+		if recorder, ok := controller.(IDataRecorder); ok {
+			plotData(recorder.getName(), stepEnds, recorder.getData())
 		}
-		plotLine(sysLine, sys.getName()+"Series.html")
 	}
+}
 
-	fmt.Println("Complete.")
+func plotData(name string, timeSeries []float64, data map[string]*[]opts.LineData) {
+	line := charts.NewLine()
+	line.SetGlobalOptions(charts.WithTitleOpts(opts.Title{
+		Title: name,
+	}))
+	line.SetXAxis(timeSeries)
+	for seriesName, series := range data {
+		line.AddSeries(seriesName, *series)
+	}
+	plotLine(line, name+"Series.html")
+}
+
+func printSteadyState(result steadyStateResult) {
+	if result.converged {
+		fmt.Printf("Steady state converged after %d iterations\n", result.iterations)
+	} else {
+		fmt.Printf("Steady state did not converge after %d iterations (residual %.3g W)\n", result.iterations, result.residual)
+	}
+	for _, name := range sortedKeys(result.temperatures) {
+		fmt.Printf("%s: %.2f °C\n", name, result.temperatures[name])
+		for _, flowName := range sortedKeys(result.heatFlows[name]) {
+			fmt.Printf("  %s: %.1f W\n", flowName, result.heatFlows[name][flowName])
+		}
+	}
+}
+
+// newSpaceHeatingLoad creates the configured space heating load, drawing from a tank
+func newSpaceHeatingLoad(config config, outdoorTemp variableIntegrator, tankTemp variableIntegrator, tankFluid *fluid) *spaceHeatingLoad {
+	heating := &spaceHeatingLoad{
+		name:              "SpaceHeating",
+		emitter:           heatEmitters[config.spaceHeating],
+		designHeatLoss:    config.heatingDesignLoad,
+		designOutdoorTemp: config.heatingDesignOutdoorTemp,
+		indoorTemp:        config.heatingIndoorTemp,
+		minSupplyTemp:     config.heatingMinSupplyTemp,
+		outdoorTemp:       outdoorTemp,
+		tankTemp:          tankTemp,
+		fluid:             tankFluid,
+	}
+	if config.heatingSupplyTemp > 0 {
+		heating.emitter.designSupplyTemp = config.heatingSupplyTemp
+	}
+	if config.heatingMaxSupplyTemp > 0 {
+		heating.emitter.maxSupplyTemp = config.heatingMaxSupplyTemp
+	}
+	return heating
+}
+
+func fatal(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, format+"\n", args...)
+	os.Exit(1)
+}
+
+func printProgressBar(progress simulationProgress) {
+	const width = 40
+	filled := int(progress.percent / 100 * width)
+	filled = max(0, min(width, filled))
+	fmt.Fprintf(os.Stderr, "\r[%s%s] %5.1f%% %s simulated, %.0f steps/s ",
+		strings.Repeat("#", filled), strings.Repeat(".", width-filled),
+		progress.percent, formatSimulatedTime(progress.time), progress.stepsPerSecond)
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
 }
 
 func plotLine(line *charts.Line, fileName string) {
@@ -0,0 +1,69 @@
+// phase change holds a fluid at its freezing or boiling point while latent heat is absorbed or released,
+// so a system can't report liquid temperatures outside the fluid's liquid range.
+// Freezing and boiling are raised as warnings so drain-back and antifreeze designs can be evaluated.
+package main
+
+import "fmt"
+
+// IWarningSystem is a system that raises warnings during commit
+type IWarningSystem interface {
+	ISystem
+	popWarnings() []string
+}
+
+// storeHeat adds energy in J to the system's fluid and dry thermal mass.
+// It works on the enthalpy relative to liquid at the freezing point, where C is the heat capacity
+// of the fluid and dry mass together and the latent heats are for the whole fluid mass:
+//
+//	H < -Lf              solid, below the freezing point
+//	-Lf <= H < 0         freezing, at the freezing point
+//	0 <= H <= Hb         liquid, where Hb = C(Tb - Tf)
+//	Hb < H < Hb + Lv     boiling, at the boiling point
+//	H >= Hb + Lv         vapor, above the boiling point
+func (fs *fluidSystem) storeHeat(energy float64) {
+	f := fs.fluid
+	capacity := fs.getHeatCapacity()
+	if fs.fluidMass == 0 {
+		// a drained system has no fluid to freeze or boil
+		fs.temperature += energy / capacity
+		return
+	}
+	fusion := fs.fluidMass * f.latentHeatFusion
+	vaporization := fs.fluidMass * f.latentHeatVaporization
+	boilingEnthalpy := capacity * (f.boilingPoint - f.freezingPoint)
+
+	h := capacity*(fs.temperature-f.freezingPoint) - fs.frozenFraction*fusion + fs.vaporFraction*vaporization
+	h += energy
+
+	wasFrozen, wasBoiling := fs.frozenFraction > 0, fs.vaporFraction > 0
+	fs.frozenFraction, fs.vaporFraction = 0.0, 0.0
+	switch {
+	case h < -fusion:
+		fs.frozenFraction = 1.0
+		fs.temperature = f.freezingPoint + (h+fusion)/capacity
+	case h < 0:
+		fs.frozenFraction = -h / fusion
+		fs.temperature = f.freezingPoint
+	case h <= boilingEnthalpy:
+		fs.temperature = f.freezingPoint + h/capacity
+	case h < boilingEnthalpy+vaporization:
+		fs.vaporFraction = (h - boilingEnthalpy) / vaporization
+		fs.temperature = f.boilingPoint
+	default:
+		fs.vaporFraction = 1.0
+		fs.temperature = f.boilingPoint + (h-boilingEnthalpy-vaporization)/capacity
+	}
+
+	if !wasFrozen && fs.frozenFraction > 0 {
+		fs.warnings = append(fs.warnings, fmt.Sprintf("%s started freezing at %g °C", fs.name, f.freezingPoint))
+	}
+	if !wasBoiling && fs.vaporFraction > 0 {
+		fs.warnings = append(fs.warnings, fmt.Sprintf("%s started boiling at %g °C (stagnation)", fs.name, f.boilingPoint))
+	}
+}
+
+func (fs *fluidSystem) popWarnings() []string {
+	warnings := fs.warnings
+	fs.warnings = nil
+	return warnings
+}
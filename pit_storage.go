@@ -0,0 +1,207 @@
+// pit storage: a large pit of water stores summer solar heat for a district heating network through the winter.
+// The pit is an upside-down truncated square pyramid dug into the ground, lined on its sloping walls and bottom,
+// and covered by a floating insulated lid. Its water is split into horizontal layers from the top down, so the hot water
+// charged at the top stays above the cooler water returned to the bottom.
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+const pitSeasonalStorage = "pit"
+
+type pitGeometry struct {
+	volume     float64 // m^3
+	depth      float64 // m
+	sideSlope  float64 // m of horizontal run per m of depth
+	bottomSide float64 // m; the side of the square bottom
+}
+
+// newPitGeometry finds the pit's bottom from its volume, depth and side slope. The top's side is t = b + 2sd, so solving
+// V = d/3(b² + t² + bt) for the bottom's side gives b = (-3k + √(36V/d - 3k²))/6, where k = 2sd.
+func newPitGeometry(volume float64, depth float64, sideSlope float64) (pitGeometry, error) {
+	if volume <= 0 || depth <= 0 || sideSlope < 0 {
+		return pitGeometry{}, errors.New("a pit needs a volume, a depth and a side slope that isn't negative")
+	}
+	k := 2 * sideSlope * depth
+	bottomSide := (-3*k + math.Sqrt(36*volume/depth-3*k*k)) / 6
+	if math.IsNaN(bottomSide) || bottomSide <= 0 {
+		return pitGeometry{}, fmt.Errorf("a %g m³ pit is too small for a depth of %g m with a side slope of %g", volume, depth, sideSlope)
+	}
+	return pitGeometry{volume: volume, depth: depth, sideSlope: sideSlope, bottomSide: bottomSide}, nil
+}
+
+// sideAt is the side of the pit's square cross-section at a depth
+func (g pitGeometry) sideAt(depth float64) float64 {
+	return g.bottomSide + 2*g.sideSlope*(g.depth-depth)
+}
+
+// volumeBetween is the volume of the frustum between two depths
+func (g pitGeometry) volumeBetween(top float64, bottom float64) float64 {
+	a, b := g.sideAt(top), g.sideAt(bottom)
+	return (bottom - top) / 3 * (a*a + b*b + a*b)
+}
+
+// wallAreaBetween is the area of the four sloping walls between two depths
+func (g pitGeometry) wallAreaBetween(top float64, bottom float64) float64 {
+	a, b := g.sideAt(top), g.sideAt(bottom)
+	return 4 * (a + b) / 2 * (bottom - top) * math.Sqrt(1+g.sideSlope*g.sideSlope)
+}
+
+// stratificationComponent is the heat passed from a layer to the one below it. Still water conducts between the layers,
+// and warmer water below a cooler layer rises and mixes with it, which is modelled by a large conductance while
+// the layers are inverted.
+type stratificationComponent struct {
+	component
+	conductance       float64 // W/K; kA/Δz
+	mixingConductance float64 // W/K
+	upperTemp         variableIntegrator
+	lowerTemp         variableIntegrator
+}
+
+func (c stratificationComponent) getHeat() float64 {
+	deltaT := c.upperTemp() - c.lowerTemp()
+	if deltaT < 0 {
+		return (c.conductance + c.mixingConductance) * deltaT
+	}
+	return c.conductance * deltaT
+}
+
+type pitStorage struct {
+	name         string
+	geometry     pitGeometry
+	layerCount   int
+	fluid        *fluid
+	lidU         float64 // W/(m^2*K)
+	wallU        float64 // W/(m^2*K); walls and bottom
+	outdoorTemp  variableIntegrator
+	soil         kusudaGround
+	day          variableIntegrator // fractional day of the year
+	layers       []*fluidSystem     // from the top down
+	lidLosses    []IHeatComponent
+	groundLosses []IHeatComponent
+}
+
+// initialize builds the layers at a starting temperature. The mixing between inverted layers is sized to halve
+// their difference over a time step, which is as fast as it can be without overshooting.
+func (p *pitStorage) initialize(temp float64, timeStep float64) error {
+	if p.layerCount < 1 {
+		return errors.New("a pit needs at least one layer")
+	}
+	thickness := p.geometry.depth / float64(p.layerCount)
+	p.layers = make([]*fluidSystem, p.layerCount)
+	for i := range p.layers {
+		top, bottom := float64(i)*thickness, float64(i+1)*thickness
+		p.layers[i] = &fluidSystem{
+			name:              fmt.Sprintf("%s%d", p.name, i+1),
+			fluidMass:         p.geometry.volumeBetween(top, bottom) * p.fluid.getDensity(temp),
+			fluid:             p.fluid,
+			temperature:       temp,
+			heatInComponents:  []IComponent{},
+			heatOutComponents: []IComponent{},
+		}
+	}
+	p.lidLosses, p.groundLosses = nil, nil
+
+	for i, layer := range p.layers {
+		top, bottom := float64(i)*thickness, float64(i+1)*thickness
+		layerTemp := func() float64 { return layer.temperature }
+		// the walls lose heat to the soil beside them
+		wallGround := &undisturbedGround{name: "Ground", depth: (top + bottom) / 2, soil: p.soil, day: p.day}
+		wallLoss := conductanceHeatComponent{
+			component: component{
+				name: "Wall Heat Loss",
+			},
+			conductance: p.wallU * p.geometry.wallAreaBetween(top, bottom),
+			currentTemp: layerTemp,
+			outputTemp:  wallGround.getTemp,
+		}
+		layer.heatOutComponents = append(layer.heatOutComponents, wallLoss)
+		p.groundLosses = append(p.groundLosses, wallLoss)
+
+		if i == 0 {
+			lidLoss := conductanceHeatComponent{
+				component: component{
+					name: "Lid Heat Loss",
+				},
+				conductance: p.lidU * math.Pow(p.geometry.sideAt(0), 2),
+				currentTemp: layerTemp,
+				outputTemp:  p.outdoorTemp,
+			}
+			layer.heatOutComponents = append(layer.heatOutComponents, lidLoss)
+			p.lidLosses = append(p.lidLosses, lidLoss)
+		}
+		if i == len(p.layers)-1 {
+			bottomGround := &undisturbedGround{name: "Ground", depth: p.geometry.depth, soil: p.soil, day: p.day}
+			bottomLoss := conductanceHeatComponent{
+				component: component{
+					name: "Bottom Heat Loss",
+				},
+				conductance: p.wallU * p.geometry.bottomSide * p.geometry.bottomSide,
+				currentTemp: layerTemp,
+				outputTemp:  bottomGround.getTemp,
+			}
+			layer.heatOutComponents = append(layer.heatOutComponents, bottomLoss)
+			p.groundLosses = append(p.groundLosses, bottomLoss)
+			continue
+		}
+
+		below := p.layers[i+1]
+		// C₁C₂/(2(C₁ + C₂)Δt) halves the difference between two layers over a step
+		upperCapacity, lowerCapacity := layer.getHeatCapacity(), below.getHeatCapacity()
+		layer.heatOutComponents = append(layer.heatOutComponents, transferHeatComponentWrapper{
+			component: component{
+				name: "Stratification",
+			},
+			wrappedComponent: stratificationComponent{
+				conductance:       p.fluid.getConductivity(temp) * math.Pow(p.geometry.sideAt(bottom), 2) / thickness,
+				mixingConductance: upperCapacity * lowerCapacity / (2 * (upperCapacity + lowerCapacity) * timeStep),
+				upperTemp:         layerTemp,
+				lowerTemp:         func() float64 { return below.temperature },
+			},
+			output: below,
+		})
+	}
+	return nil
+}
+
+// addCharge returns hot water at supplyTemp to the top layer, and takes the same flow from the bottom layer,
+// moving the water down through the layers
+func (p *pitStorage) addCharge(flowMass variableIntegrator, supplyTemp variableIntegrator) {
+	upstreamTemp := supplyTemp
+	for _, layer := range p.layers {
+		layer.addInflowComponent("Charge Inflow", flowMass, upstreamTemp)
+		upstreamTemp = func() float64 { return layer.temperature }
+	}
+}
+
+// addDischarge takes hot water from the top layer, and returns the same flow at returnTemp to the bottom layer,
+// moving the water up through the layers
+func (p *pitStorage) addDischarge(flowMass variableIntegrator, returnTemp variableIntegrator) {
+	upstreamTemp := returnTemp
+	for i := len(p.layers) - 1; i >= 0; i-- {
+		layer := p.layers[i]
+		layer.addInflowComponent("Discharge Inflow", flowMass, upstreamTemp)
+		upstreamTemp = func() float64 { return layer.temperature }
+	}
+}
+
+func (p *pitStorage) getTopTemp() float64 {
+	return p.layers[0].temperature
+}
+
+func (p *pitStorage) getBottomTemp() float64 {
+	return p.layers[len(p.layers)-1].temperature
+}
+
+// getStoredEnergy is the heat of the pit's water above liquid at 0 °C, including any latent heat
+func (p *pitStorage) getStoredEnergy() float64 {
+	energy := 0.0
+	for _, layer := range p.layers {
+		latentHeat := layer.vaporFraction*p.fluid.latentHeatVaporization - layer.frozenFraction*p.fluid.latentHeatFusion
+		energy += layer.fluidMass * (p.fluid.getEnthalpy(layer.temperature) + latentHeat)
+	}
+	return energy
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPitGeometry(t *testing.T) {
+	g, err := newPitGeometry(60000, 16, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if math.Abs(g.volumeBetween(0, 16)-60000) > 1e-6 {
+		t.Errorf("expected the whole pit to hold 60000 m³, got %v m³", g.volumeBetween(0, 16))
+	}
+	if math.Abs(g.sideAt(0)-(g.bottomSide+64)) > 1e-9 {
+		t.Errorf("expected the top to be 64 m wider than the bottom, got %v m and %v m", g.sideAt(0), g.bottomSide)
+	}
+	// a pit with vertical walls is a box
+	box, _ := newPitGeometry(1000, 10, 0)
+	if math.Abs(box.bottomSide-10) > 1e-9 || math.Abs(box.wallAreaBetween(0, 10)-400) > 1e-9 {
+		t.Errorf("unexpected box of side %v m and wall area %v m²", box.bottomSide, box.wallAreaBetween(0, 10))
+	}
+
+	if _, err := newPitGeometry(100, 16, 2); err == nil {
+		t.Error("expected an error for a pit too small for its slopes")
+	}
+}
+
+func TestPitStorage(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	geometry, _ := newPitGeometry(1000, 10, 0)
+	pit := &pitStorage{
+		name:        "Pit",
+		geometry:    geometry,
+		layerCount:  4,
+		fluid:       water,
+		outdoorTemp: mockVariableIntegrator(0),
+		soil:        kusudaGround{meanTemp: 10, diffusivity: 1e-6},
+		day:         mockVariableIntegrator(0),
+	}
+	if err := pit.initialize(40, 3600); err != nil {
+		t.Fatal(err)
+	}
+	if len(pit.layers) != 4 || pit.layers[0].getName() != "Pit1" {
+		t.Fatalf("unexpected layers %v", pit.layers)
+	}
+	if len(pit.lidLosses) != 1 || len(pit.groundLosses) != 5 {
+		t.Errorf("expected a lid loss and five ground losses, got %v and %v", len(pit.lidLosses), len(pit.groundLosses))
+	}
+
+	// charging and discharging at once, with no losses: the heat charged less the heat discharged is stored
+	chargeFlow, chargeTemp := 1.0, 80.0
+	dischargeFlow, returnTemp := 0.5, 30.0
+	pit.addCharge(func() float64 { return chargeFlow }, func() float64 { return chargeTemp })
+	pit.addDischarge(func() float64 { return dischargeFlow }, func() float64 { return returnTemp })
+	stored := pit.getStoredEnergy()
+	systems := []ISystem{}
+	for _, layer := range pit.layers {
+		systems = append(systems, layer)
+	}
+	// each stream brings in ṁc(T_in - T_out), leaving at the temperature of the layer it's taken from; the layers
+	// weigh the flow by their own specific heats, so the sum only matches the stored heat closely
+	exchanged := 0.0
+	for range 10 {
+		chargeOutTemp, dischargeOutTemp := pit.getBottomTemp(), pit.getTopTemp()
+		exchanged += chargeFlow * water.getSpecificHeat(chargeOutTemp) * (chargeTemp - chargeOutTemp) * 3600
+		exchanged += dischargeFlow * water.getSpecificHeat(dischargeOutTemp) * (returnTemp - dischargeOutTemp) * 3600
+		for _, sys := range systems {
+			sys.reset()
+		}
+		for _, sys := range systems {
+			sys.step()
+		}
+		for _, sys := range systems {
+			sys.commit(3600)
+		}
+	}
+	if pit.getTopTemp() <= 40 || pit.getTopTemp() <= pit.getBottomTemp() {
+		t.Errorf("expected the charge to warm the top above the bottom, got %v °C and %v °C", pit.getTopTemp(), pit.getBottomTemp())
+	}
+	if gained := pit.getStoredEnergy() - stored; gained <= 0 || math.Abs(gained-exchanged)/exchanged > 1e-3 {
+		t.Errorf("expected the pit to store the %v J exchanged, got %v J", exchanged, gained)
+	}
+
+	// an inverted pair of layers mixes, and the mixing only halves their difference in one step
+	c := stratificationComponent{
+		conductance:       1,
+		mixingConductance: 1e6 * 1e6 / (2 * 2e6 * 60),
+		upperTemp:         mockVariableIntegrator(20),
+		lowerTemp:         mockVariableIntegrator(40),
+	}
+	upperGain := -c.getHeat() * 60 / 1e6
+	if math.Abs(upperGain-5) > 1e-2 {
+		t.Errorf("expected the upper layer to gain 5 K, got %v K", upperGain)
+	}
+	c.upperTemp, c.lowerTemp = mockVariableIntegrator(40), mockVariableIntegrator(20)
+	if c.getHeat() != 20 {
+		t.Errorf("expected conduction alone in a stratified pit, got %v W", c.getHeat())
+	}
+}
@@ -0,0 +1,167 @@
+// pump: a variable-speed circulator with a head-flow curve.
+// Every step the pump's operating flow is solved against the loop's hydraulic resistances,
+// and its electrical consumption is recorded for the parasitic energy calculation.
+package main
+
+import "math"
+
+const (
+	fixedPumpModel = "fixed" // delivers the rated flow scaled by speed, regardless of the loop
+	curvePumpModel = "curve" // solves the pump curve against the loop's resistance
+
+	pwmPumpSignal     = "pwm"     // duty cycle, 0 to 100%
+	voltagePumpSignal = "voltage" // 0 to 10 V
+
+	constantPumpControl     = "constant"     // always sends PUMP_SIGNAL
+	differentialPumpControl = "differential" // switches on the collector to tank temperature difference
+
+	pumpSolverIterations = 60
+)
+
+// pumpSpeed converts a control signal to a speed fraction between 0 and 1
+func pumpSpeed(signalType string, signal float64) float64 {
+	speed := signal / 100
+	if signalType == voltagePumpSignal {
+		speed = signal / 10
+	}
+	return math.Max(0, math.Min(1, speed))
+}
+
+type pump struct {
+	name          string
+	model         string
+	ratedFlowMass float64 // kg/s; flow at full speed for the fixed model
+	shutoffHead   float64 // m; head at full speed and no flow
+	maxFlowVolume float64 // m^3/s; flow at full speed and no head
+	efficiency    float64 // wire to water
+	signalType    string
+	signal        variableIntegrator
+	loop          []IHydraulicResistance
+	staticHead    variableIntegrator // optional; m of lift on top of the loop's resistance, e.g. refilling a drained collector
+	fluid         *fluid
+	fluidTemp     variableIntegrator
+	// the operating point, solved every step
+	flowMass         float64 // kg/s
+	head             float64 // m
+	electricalPower  float64 // W
+	electricalEnergy float64 // J; total over the run
+	dataRecorder
+}
+
+func (p *pump) getName() string {
+	return p.name
+}
+
+func (p *pump) getFlowMass() float64 {
+	return p.flowMass
+}
+
+func (p *pump) update(time float64, timeStep float64) {
+	speed := pumpSpeed(p.signalType, p.signal())
+	temp := p.fluidTemp()
+	density := p.fluid.getDensity(temp)
+
+	flowVolume := 0.0
+	if speed > 0 {
+		if p.model == curvePumpModel {
+			flowVolume = p.solveOperatingFlow(speed, density, temp)
+		} else {
+			flowVolume = speed * p.ratedFlowMass / density
+		}
+	}
+	p.flowMass = flowVolume * density
+	p.head = loopPressureDrop(p.loop, flowVolume, p.fluid, temp)/(density*gravity) + p.getStaticHead()
+
+	// P = ρgQH/η
+	p.electricalPower = 0.0
+	if p.efficiency > 0 {
+		p.electricalPower = density * gravity * flowVolume * p.head / p.efficiency
+	}
+	p.electricalEnergy += p.electricalPower * timeStep
+
+	p.addDataPoint("Flow Rate", p.flowMass)
+	p.addDataPoint("Electrical Power", p.electricalPower)
+}
+
+// solveOperatingFlow finds the flow where the pump head equals the loop's pressure drop.
+// The pump curve follows the affinity laws: H = s²H₀ - H₀(Q/Q_max)²
+func (p *pump) solveOperatingFlow(speed float64, density float64, temp float64) float64 {
+	excessHead := func(flowVolume float64) float64 {
+		pumpHead := speed*speed*p.shutoffHead - p.shutoffHead*math.Pow(flowVolume/p.maxFlowVolume, 2)
+		return pumpHead - loopPressureDrop(p.loop, flowVolume, p.fluid, temp)/(density*gravity) - p.getStaticHead()
+	}
+
+	// the pump head is positive at no flow and zero at s·Q_max, so the operating point is bracketed;
+	// a pump too weak to lift the static head solves to no flow
+	low, high := 0.0, speed*p.maxFlowVolume
+	for i := 0; i < pumpSolverIterations; i++ {
+		mid := (low + high) / 2
+		if excessHead(mid) > 0 {
+			low = mid
+		} else {
+			high = mid
+		}
+	}
+	return (low + high) / 2
+}
+
+func (p *pump) getStaticHead() float64 {
+	if p.staticHead == nil {
+		return 0.0
+	}
+	return p.staticHead()
+}
+
+func (p *pump) getState() map[string]float64 {
+	return map[string]float64{"electricalEnergy": p.electricalEnergy}
+}
+
+func (p *pump) setState(state map[string]float64) error {
+	p.electricalEnergy = state["electricalEnergy"]
+	return nil
+}
+
+// differentialController switches the pump on when the collector is hotter than the tank by onDeltaT,
+// and off when the difference falls below offDeltaT
+type differentialController struct {
+	name      string
+	onDeltaT  float64 // K
+	offDeltaT float64 // K
+	onSignal  float64 // signal sent to the pump while on
+	hotTemp   variableIntegrator
+	coldTemp  variableIntegrator
+	on        bool
+}
+
+func (c *differentialController) getName() string {
+	return c.name
+}
+
+func (c *differentialController) update(time float64, timeStep float64) {
+	deltaT := c.hotTemp() - c.coldTemp()
+	if c.on && deltaT < c.offDeltaT {
+		c.on = false
+	} else if !c.on && deltaT > c.onDeltaT {
+		c.on = true
+	}
+}
+
+func (c *differentialController) getSignal() float64 {
+	if c.on {
+		return c.onSignal
+	}
+	return 0.0
+}
+
+func (c *differentialController) getState() map[string]float64 {
+	on := 0.0
+	if c.on {
+		on = 1.0
+	}
+	return map[string]float64{"on": on}
+}
+
+func (c *differentialController) setState(state map[string]float64) error {
+	c.on = state["on"] != 0
+	return nil
+}
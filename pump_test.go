@@ -0,0 +1,154 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPumpSpeed(t *testing.T) {
+	tests := []struct {
+		name       string
+		signalType string
+		signal     float64
+		expected   float64
+	}{
+		{name: "PWM", signalType: pwmPumpSignal, signal: 50, expected: 0.5},
+		{name: "Voltage", signalType: voltagePumpSignal, signal: 5, expected: 0.5},
+		{name: "Clamped high", signalType: pwmPumpSignal, signal: 120, expected: 1.0},
+		{name: "Clamped low", signalType: voltagePumpSignal, signal: -1, expected: 0.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if speed := pumpSpeed(tt.signalType, tt.signal); math.Abs(speed-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected %v, got %v", tt.expected, speed)
+			}
+		})
+	}
+}
+
+func TestPressureDrops(t *testing.T) {
+	water := fluids[waterFluidName]
+
+	collector := quadraticResistance{component{"Collector"}, 3000, 2e-4}
+	if drop := collector.getPressureDrop(4e-4, water, 20); math.Abs(drop-12000) > 1e-6 {
+		t.Errorf("expected the pressure drop to scale with flow squared, got %v", drop)
+	}
+
+	pipe := pipeResistance{component{"Pipe"}, 20, 0.016, 1.5e-6, 0}
+	low := pipe.getPressureDrop(1e-4, water, 20)
+	high := pipe.getPressureDrop(2e-4, water, 20)
+	if low <= 0 || high <= 2*low {
+		t.Errorf("expected the pipe pressure drop to grow faster than flow, got %v and %v", low, high)
+	}
+	if drop := pipe.getPressureDrop(0, water, 20); drop != 0.0 {
+		t.Errorf("expected no pressure drop without flow, got %v", drop)
+	}
+}
+
+func TestPumpOperatingPoint(t *testing.T) {
+	water := fluids[waterFluidName]
+	p := pump{
+		name:          "Pump",
+		model:         curvePumpModel,
+		shutoffHead:   6,
+		maxFlowVolume: 1e-3,
+		efficiency:    0.25,
+		signalType:    pwmPumpSignal,
+		signal:        mockVariableIntegrator(100),
+		loop:          []IHydraulicResistance{quadraticResistance{component{"Collector"}, 3000, 2e-4}},
+		fluid:         water,
+		fluidTemp:     mockVariableIntegrator(20),
+	}
+	p.update(0, 1)
+
+	// the pump curve and loop curve cross where the heads are equal
+	density := water.getDensity(20)
+	flowVolume := p.flowMass / density
+	pumpHead := p.shutoffHead - p.shutoffHead*math.Pow(flowVolume/p.maxFlowVolume, 2)
+	if math.Abs(pumpHead-p.head) > 1e-6 {
+		t.Errorf("expected pump head %v to match loop head %v", pumpHead, p.head)
+	}
+	expectedPower := density * gravity * flowVolume * p.head / p.efficiency
+	if math.Abs(p.electricalPower-expectedPower) > 1e-6 || math.Abs(p.electricalEnergy-expectedPower) > 1e-6 {
+		t.Errorf("expected electrical power %v, got %v", expectedPower, p.electricalPower)
+	}
+
+	// a slower pump delivers less flow
+	fullSpeedFlow := p.flowMass
+	p.signal = mockVariableIntegrator(50)
+	p.update(1, 1)
+	if p.flowMass <= 0 || p.flowMass >= fullSpeedFlow {
+		t.Errorf("expected less flow at half speed, got %v vs %v", p.flowMass, fullSpeedFlow)
+	}
+
+	p.signal = mockVariableIntegrator(0)
+	p.update(2, 1)
+	if p.flowMass != 0.0 || p.electricalPower != 0.0 {
+		t.Errorf("expected no flow or power when stopped, got %v kg/s and %v W", p.flowMass, p.electricalPower)
+	}
+}
+
+func TestPumpStaticHead(t *testing.T) {
+	water := fluids[waterFluidName]
+	staticHead := 0.0
+	p := pump{
+		name:          "Pump",
+		model:         curvePumpModel,
+		shutoffHead:   6,
+		maxFlowVolume: 1e-3,
+		efficiency:    0.25,
+		signalType:    pwmPumpSignal,
+		signal:        mockVariableIntegrator(100),
+		loop:          []IHydraulicResistance{quadraticResistance{component{"Collector"}, 3000, 2e-4}},
+		staticHead:    func() float64 { return staticHead },
+		fluid:         water,
+		fluidTemp:     mockVariableIntegrator(20),
+	}
+	p.update(0, 1)
+	openFlow := p.flowMass
+
+	// lifting the fluid leaves less head for the loop
+	staticHead = 3
+	p.update(1, 1)
+	if p.flowMass <= 0 || p.flowMass >= openFlow {
+		t.Errorf("expected less flow against a lift, got %v vs %v", p.flowMass, openFlow)
+	}
+
+	// a lift above the shutoff head stalls the pump
+	staticHead = 7
+	p.update(2, 1)
+	if p.flowMass > 1e-6 {
+		t.Errorf("expected no flow against a lift above the shutoff head, got %v", p.flowMass)
+	}
+}
+
+func TestDifferentialController(t *testing.T) {
+	hotTemp := 20.0
+	c := differentialController{
+		name:      "PumpController",
+		onDeltaT:  6,
+		offDeltaT: 2,
+		onSignal:  100,
+		hotTemp:   func() float64 { return hotTemp },
+		coldTemp:  mockVariableIntegrator(20),
+	}
+
+	// the controller only changes state outside the hysteresis band
+	steps := []struct {
+		hotTemp  float64
+		expected float64
+	}{
+		{hotTemp: 24, expected: 0},
+		{hotTemp: 27, expected: 100},
+		{hotTemp: 23, expected: 100},
+		{hotTemp: 21, expected: 0},
+		{hotTemp: 25, expected: 0},
+	}
+	for i, step := range steps {
+		hotTemp = step.hotTemp
+		c.update(float64(i), 1)
+		if signal := c.getSignal(); signal != step.expected {
+			t.Errorf("step %d: expected signal %v, got %v", i, step.expected, signal)
+		}
+	}
+}
@@ -0,0 +1,50 @@
+// photovoltaic-thermal (PVT) collector: PV cells laminated onto the absorber turn part of the absorbed radiation into
+// electricity, and the rest heats the fluid. The cells lose efficiency as they warm up, so cooling the panel
+// with the collector loop raises the PV yield.
+package main
+
+const (
+	flatPlateCollector = "flat-plate"
+	pvtCollector       = "pvt"
+)
+
+// pvCells is the electrical output of a PVT collector, which leaves the absorber as heat it no longer receives
+type pvCells struct {
+	component
+	referenceEfficiency float64 // at referenceTemp
+	tempCoefficient     float64 // 1/K; β
+	referenceTemp       float64 // Celsius
+	surfaceArea         float64
+	incidentRadiation   variableIntegrator
+	cellTemp            variableIntegrator
+	electricalPower     float64 // W; during the current step
+	electricalEnergy    float64 // J; total over the run
+}
+
+// getEfficiency is the cells' electrical efficiency at their current temperature: η = ηᵣ(1 - β(T꜀ - Tᵣ))
+func (c pvCells) getEfficiency() float64 {
+	efficiency := c.referenceEfficiency * (1 - c.tempCoefficient*(c.cellTemp()-c.referenceTemp))
+	if efficiency < 0 {
+		return 0.0
+	}
+	return efficiency
+}
+
+func (c *pvCells) getHeat() float64 {
+	// P = ηIA
+	c.electricalPower = c.getEfficiency() * c.incidentRadiation() * c.surfaceArea
+	return c.electricalPower
+}
+
+// commit adds the step's electrical output to the run's total
+func (c *pvCells) commit(timeStep float64) {
+	c.electricalEnergy += c.electricalPower * timeStep
+}
+
+func (c *pvCells) getState() map[string]float64 {
+	return map[string]float64{"electricalEnergy": c.electricalEnergy}
+}
+
+func (c *pvCells) setState(state map[string]float64) {
+	c.electricalEnergy = state["electricalEnergy"]
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestPVCells(t *testing.T) {
+	cellTemp := 25.0
+	cells := pvCells{
+		referenceEfficiency: 0.2,
+		tempCoefficient:     0.004,
+		referenceTemp:       25,
+		surfaceArea:         2,
+		incidentRadiation:   mockVariableIntegrator(1000),
+		cellTemp:            func() float64 { return cellTemp },
+	}
+	if power := cells.getHeat(); math.Abs(power-400) > float64EqualityThreshold {
+		t.Errorf("expected 400 W at the reference temperature, got %v W", power)
+	}
+	cellTemp = 75
+	if power := cells.getHeat(); math.Abs(power-320) > float64EqualityThreshold {
+		t.Errorf("expected 320 W at 75 °C, got %v W", power)
+	}
+	cellTemp = 400
+	if power := cells.getHeat(); power != 0 {
+		t.Errorf("expected no output past zero efficiency, got %v W", power)
+	}
+}
+
+func TestSolarPanelPVT(t *testing.T) {
+	sp := solarPanel{
+		fluidSystem: fluidSystem{
+			name:        "SolarPanel",
+			ambientTemp: 20,
+			temperature: 45,
+		},
+		panelArea:       2,
+		panelEfficiency: 0.7,
+		solarIrradiance: 1000,
+		pv:              &pvCells{referenceEfficiency: 0.2, tempCoefficient: 0.004, referenceTemp: 25},
+	}
+	sp.initialize(nil, nil)
+	sp.reset()
+	sp.step()
+	sp.commit(10)
+
+	// the electricity is taken out of the absorbed radiation
+	if power := (*sp.powerData["Electrical Output"])[0].Value.(float64); math.Abs(power-368) > 1e-9 {
+		t.Errorf("expected 368 W of electricity, got %v W", power)
+	}
+	if math.Abs(sp.pv.electricalEnergy-3680) > 1e-6 {
+		t.Errorf("expected 3680 J of electricity, got %v J", sp.pv.electricalEnergy)
+	}
+	if math.Abs(sp.getNetHeat()-(1400-368)) > 1e-9 {
+		t.Errorf("expected %v W stored, got %v W", 1400-368, sp.getNetHeat())
+	}
+
+	// the total carries over a checkpoint
+	restored := solarPanel{pv: &pvCells{}}
+	if err := restored.setState(sp.getState()); err != nil || restored.pv.electricalEnergy != sp.pv.electricalEnergy {
+		t.Errorf("expected %v J restored, got %v J (%v)", sp.pv.electricalEnergy, restored.pv.electricalEnergy, err)
+	}
+}
@@ -0,0 +1,67 @@
+// schedules are time series of an input, e.g. wind speed, loaded from a CSV file and linearly interpolated
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+)
+
+type schedule struct {
+	times  []float64 // s; ascending
+	values []float64
+}
+
+// loadScheduleCSV reads a schedule from a CSV file with rows of hour,value. A header row is skipped.
+func loadScheduleCSV(fileName string) (schedule, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return schedule{}, err
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return schedule{}, err
+	}
+
+	s := schedule{}
+	for i, row := range rows {
+		if len(row) < 2 {
+			return schedule{}, fmt.Errorf("%s line %d: expected hour,value", fileName, i+1)
+		}
+		hour, hourErr := strconv.ParseFloat(row[0], 64)
+		value, valueErr := strconv.ParseFloat(row[1], 64)
+		if hourErr != nil || valueErr != nil {
+			if i == 0 {
+				continue // header
+			}
+			return schedule{}, fmt.Errorf("%s line %d: could not parse hour,value", fileName, i+1)
+		}
+		if len(s.times) > 0 && hour*60*60 <= s.times[len(s.times)-1] {
+			return schedule{}, fmt.Errorf("%s line %d: hours must be increasing", fileName, i+1)
+		}
+		s.times = append(s.times, hour*60*60)
+		s.values = append(s.values, value)
+	}
+	if len(s.times) == 0 {
+		return schedule{}, errors.New(fileName + ": schedule is empty")
+	}
+	return s, nil
+}
+
+// at returns the value at time t in seconds, holding the first and last values outside the schedule
+func (s schedule) at(t float64) float64 {
+	i := sort.SearchFloat64s(s.times, t)
+	if i == 0 {
+		return s.values[0]
+	}
+	if i == len(s.times) {
+		return s.values[len(s.values)-1]
+	}
+	fraction := (t - s.times[i-1]) / (s.times[i] - s.times[i-1])
+	return s.values[i-1] + fraction*(s.values[i]-s.values[i-1])
+}
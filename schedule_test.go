@@ -0,0 +1,44 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadScheduleCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "schedule.csv")
+	if err := os.WriteFile(fileName, []byte("hour,value\n0,2\n1,4\n3,0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := loadScheduleCSV(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		time     float64
+		expected float64
+	}{
+		{time: -60, expected: 2},
+		{time: 0, expected: 2},
+		{time: 30 * 60, expected: 3},
+		{time: 2 * 60 * 60, expected: 2},
+		{time: 5 * 60 * 60, expected: 0},
+	}
+	for _, tt := range tests {
+		if value := s.at(tt.time); value != tt.expected {
+			t.Errorf("expected %v at %vs, got %v", tt.expected, tt.time, value)
+		}
+	}
+}
+
+func TestLoadScheduleCSV_Invalid(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "schedule.csv")
+	if err := os.WriteFile(fileName, []byte("0,2\n2,4\n1,0\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := loadScheduleCSV(fileName); err == nil {
+		t.Error("expected error for decreasing hours")
+	}
+}
@@ -0,0 +1,320 @@
+// seasonal storage runs: a collector field charges a pit through the summer, and a district heating load discharges it
+// through the winter. Runs span several years with hourly steps, so the collector field is modelled by its steady-state
+// efficiency curve rather than as a system with its own temperature. An energy balance is kept for each year of the run.
+package main
+
+import (
+	"fmt"
+	"math"
+)
+
+const yearLength = 365 * 24 * 60 * 60 // s
+
+// collectorField is a large collector array, whose heat output follows its efficiency curve at the inlet temperature:
+// q = A(η₀G - a₁(Tᵢₙ - Tₐ) - a₂(Tᵢₙ - Tₐ)²). Its pumps run while the field gains heat, until the storage is full.
+type collectorField struct {
+	name       string
+	absorption heatAborptionComponent
+	loss       collectorLossComponent // at the inlet temperature
+	flowRate   float64                // kg/(s*m^2) of collector
+	inletTemp  variableIntegrator
+	fluid      *fluid
+	storeTemp  variableIntegrator // the hottest part of the storage
+	maxTemp    float64            // Celsius; of the storage
+	// the field's operating point, updated every step
+	flowMass        float64 // kg/s
+	outletTemp      float64 // Celsius
+	collectedEnergy float64 // J; total over the run
+	dataRecorder
+}
+
+func (f *collectorField) getName() string {
+	return f.name
+}
+
+func (f *collectorField) update(time float64, timeStep float64) {
+	inletTemp := f.inletTemp()
+	heat := f.absorption.getHeat() - f.loss.getHeat()
+	f.flowMass, f.outletTemp = 0.0, inletTemp
+	if heat > 0 && f.storeTemp() < f.maxTemp {
+		// Tₒᵤₜ = Tᵢₙ + q/(ṁC)
+		f.flowMass = f.flowRate * f.absorption.surfaceArea
+		f.outletTemp = inletTemp + heat/(f.flowMass*f.fluid.getSpecificHeat(inletTemp))
+	} else {
+		heat = 0.0
+	}
+	f.collectedEnergy += heat * timeStep
+
+	f.addDataPoint("Collected Power", heat)
+	f.addDataPoint("Outlet Temperature", f.outletTemp)
+}
+
+func (f *collectorField) getFlowMass() float64 {
+	return f.flowMass
+}
+
+func (f *collectorField) getOutletTemp() float64 {
+	return f.outletTemp
+}
+
+func (f *collectorField) getState() map[string]float64 {
+	return map[string]float64{
+		"collectedEnergy": f.collectedEnergy,
+	}
+}
+
+func (f *collectorField) setState(state map[string]float64) error {
+	f.collectedEnergy = state["collectedEnergy"]
+	return nil
+}
+
+// energyYear is a year's energy balance of the storage, in J
+type energyYear struct {
+	duration     float64 // s; shorter than a year if the run ended part way through it
+	collected    float64
+	demand       float64
+	delivered    float64
+	lidLoss      float64
+	groundLoss   float64
+	storedChange float64
+}
+
+// getResidual is the energy unaccounted for, which should be close to zero
+func (y energyYear) getResidual() float64 {
+	return y.collected - y.delivered - y.lidLoss - y.groundLoss - y.storedChange
+}
+
+// getStorageEfficiency is the share of the charged heat that was discharged or is still stored: (Qₒᵤₜ + ΔU)/Qᵢₙ
+func (y energyYear) getStorageEfficiency() float64 {
+	if y.collected == 0 {
+		return 0.0
+	}
+	return (y.delivered + y.storedChange) / y.collected
+}
+
+// getSolarFraction is the share of the heating demand covered by the storage
+func (y energyYear) getSolarFraction() float64 {
+	if y.demand == 0 {
+		return 0.0
+	}
+	return y.delivered / y.demand
+}
+
+// annualEnergyBalance splits the run into years of the storage's energy flows. It must be updated before the controllers
+// whose totals it reads, so the totals it sees at the start of a step only cover the steps before it.
+type annualEnergyBalance struct {
+	name            string
+	collectedEnergy variableIntegrator // J; totals over the run so far
+	demandEnergy    variableIntegrator
+	deliveredEnergy variableIntegrator
+	storedEnergy    variableIntegrator // J
+	lidLosses       []IHeatComponent
+	groundLosses    []IHeatComponent
+	years           []energyYear // completed years
+	current         energyYear   // the current year's losses so far
+	yearElapsed     float64      // s
+	// the totals at the start of the current year
+	startCollected float64
+	startDemand    float64
+	startDelivered float64
+	startStored    float64
+}
+
+func (b *annualEnergyBalance) getName() string {
+	return b.name
+}
+
+// startYear takes the totals at the start of a year
+func (b *annualEnergyBalance) startYear() {
+	b.current = energyYear{}
+	b.yearElapsed = 0.0
+	b.startCollected = b.collectedEnergy()
+	b.startDemand = b.demandEnergy()
+	b.startDelivered = b.deliveredEnergy()
+	b.startStored = b.storedEnergy()
+}
+
+func (b *annualEnergyBalance) update(time float64, timeStep float64) {
+	if b.yearElapsed >= yearLength-float64EqualityThreshold {
+		b.finish()
+	}
+	for _, loss := range b.lidLosses {
+		b.current.lidLoss += loss.getHeat() * timeStep
+	}
+	for _, loss := range b.groundLosses {
+		b.current.groundLoss += loss.getHeat() * timeStep
+	}
+	b.yearElapsed += timeStep
+}
+
+// finish closes the current year, which is only part of a year if the run ended early
+func (b *annualEnergyBalance) finish() {
+	if b.yearElapsed == 0 {
+		return
+	}
+	year := b.current
+	year.duration = b.yearElapsed
+	year.collected = b.collectedEnergy() - b.startCollected
+	year.demand = b.demandEnergy() - b.startDemand
+	year.delivered = b.deliveredEnergy() - b.startDelivered
+	year.storedChange = b.storedEnergy() - b.startStored
+	b.years = append(b.years, year)
+	b.startYear()
+}
+
+func (b *annualEnergyBalance) getState() map[string]float64 {
+	state := map[string]float64{
+		"years":          float64(len(b.years)),
+		"yearElapsed":    b.yearElapsed,
+		"lidLoss":        b.current.lidLoss,
+		"groundLoss":     b.current.groundLoss,
+		"startCollected": b.startCollected,
+		"startDemand":    b.startDemand,
+		"startDelivered": b.startDelivered,
+		"startStored":    b.startStored,
+	}
+	for i, year := range b.years {
+		prefix := fmt.Sprintf("year%d.", i+1)
+		state[prefix+"duration"] = year.duration
+		state[prefix+"collected"] = year.collected
+		state[prefix+"demand"] = year.demand
+		state[prefix+"delivered"] = year.delivered
+		state[prefix+"lidLoss"] = year.lidLoss
+		state[prefix+"groundLoss"] = year.groundLoss
+		state[prefix+"storedChange"] = year.storedChange
+	}
+	return state
+}
+
+func (b *annualEnergyBalance) setState(state map[string]float64) error {
+	b.years = nil
+	for i := range int(state["years"]) {
+		prefix := fmt.Sprintf("year%d.", i+1)
+		b.years = append(b.years, energyYear{
+			duration:     state[prefix+"duration"],
+			collected:    state[prefix+"collected"],
+			demand:       state[prefix+"demand"],
+			delivered:    state[prefix+"delivered"],
+			lidLoss:      state[prefix+"lidLoss"],
+			groundLoss:   state[prefix+"groundLoss"],
+			storedChange: state[prefix+"storedChange"],
+		})
+	}
+	b.current = energyYear{lidLoss: state["lidLoss"], groundLoss: state["groundLoss"]}
+	b.yearElapsed = state["yearElapsed"]
+	b.startCollected = state["startCollected"]
+	b.startDemand = state["startDemand"]
+	b.startDelivered = state["startDelivered"]
+	b.startStored = state["startStored"]
+	return nil
+}
+
+// runSeasonalStorage runs a collector field charging a pit, and the space heating load drawing from it, for SEASONAL_YEARS
+func runSeasonalStorage(config config, sim *simulation, soil kusudaGround, dayOfYear variableIntegrator, sunAt func() sunPosition) {
+	if config.beamIrradiance <= 0 && config.diffuseIrradiance <= 0 {
+		fatal("SEASONAL_STORAGE requires BEAM_IRRADIANCE or DIFFUSE_IRRADIANCE")
+	}
+	if config.steadyState {
+		fatal("STEADY_STATE is not supported with SEASONAL_STORAGE")
+	}
+	pitFluid, err := getFluid(config.tankFluid)
+	if err != nil {
+		fatal("TANK_FLUID: %v", err)
+	}
+	geometry, err := newPitGeometry(config.pitVolume, config.pitDepth, config.pitSideSlope)
+	if err != nil {
+		fatal("%v", err)
+	}
+	sim.duration = config.seasonalYears * yearLength
+
+	// the air follows the same annual and daily waves as the ground's surface
+	outdoorTemp := func() float64 { return soil.tempAt(0, dayOfYear()) }
+	pit := &pitStorage{
+		name:        "PitStorage",
+		geometry:    geometry,
+		layerCount:  config.pitLayers,
+		fluid:       pitFluid,
+		lidU:        config.pitLidU,
+		wallU:       config.pitWallU,
+		outdoorTemp: outdoorTemp,
+		soil:        soil,
+		day:         dayOfYear,
+	}
+	if err := pit.initialize(config.pitTemp, sim.timeStep); err != nil {
+		fatal("%v", err)
+	}
+
+	// G = G_bn·cos θ + G_dh(1 + cos β)/2
+	irradiance := func() float64 {
+		sun := sunAt()
+		beam := 0.0
+		if sun.altitude > 0 {
+			incidence := getIncidenceAngles(sun, config.panelTilt, config.panelAzimuth)
+			beam = config.beamIrradiance * math.Max(0, math.Cos(incidence.angle*degreesToRadians))
+		}
+		return beam + config.diffuseIrradiance*tiltedSkyViewFactor(config.panelTilt)
+	}
+	field := &collectorField{
+		name: "CollectorField",
+		absorption: heatAborptionComponent{
+			efficiency:        config.fieldEfficiency,
+			incidentRadiation: irradiance,
+			surfaceArea:       config.fieldArea,
+		},
+		loss: collectorLossComponent{
+			a1:          config.fieldA1,
+			a2:          config.fieldA2,
+			surfaceArea: config.fieldArea,
+			currentTemp: pit.getBottomTemp,
+			ambientTemp: outdoorTemp,
+		},
+		flowRate:  config.fieldFlowRate,
+		inletTemp: pit.getBottomTemp,
+		fluid:     pitFluid,
+		storeTemp: pit.getTopTemp,
+		maxTemp:   config.pitMaxTemp,
+	}
+	pit.addCharge(field.getFlowMass, field.getOutletTemp)
+
+	balance := &annualEnergyBalance{
+		name:            "EnergyBalance",
+		collectedEnergy: func() float64 { return field.collectedEnergy },
+		demandEnergy:    func() float64 { return 0.0 },
+		deliveredEnergy: func() float64 { return 0.0 },
+		storedEnergy:    pit.getStoredEnergy,
+		lidLosses:       pit.lidLosses,
+		groundLosses:    pit.groundLosses,
+	}
+	sim.controllers = []IController{balance, field}
+	if config.spaceHeating != "" {
+		// the district heating network draws from the top of the pit, and returns to the bottom
+		heating := newSpaceHeatingLoad(config, outdoorTemp, pit.getTopTemp, pitFluid)
+		pit.addDischarge(func() float64 { return heating.tankFlowMass }, func() float64 { return heating.returnTemp })
+		balance.demandEnergy = func() float64 { return heating.demandEnergy }
+		balance.deliveredEnergy = func() float64 { return heating.deliveredEnergy }
+		sim.controllers = append(sim.controllers, heating)
+	}
+	balance.startYear()
+
+	for _, layer := range pit.layers {
+		sim.systems = append(sim.systems, layer)
+	}
+	prepareRun(sim, config)
+	result := runWithProgress(sim)
+	balance.finish()
+
+	const megawattHour = 1e6 * 60 * 60
+	for i, year := range balance.years {
+		label := fmt.Sprintf("Year %d", i+1)
+		if year.duration < yearLength-float64EqualityThreshold {
+			label += fmt.Sprintf(" (%s)", formatSimulatedTime(year.duration))
+		}
+		fmt.Printf("%s: %.1f MWh collected, %.1f MWh of %.1f MWh demand delivered (solar fraction %.1f%%)\n",
+			label, year.collected/megawattHour, year.delivered/megawattHour, year.demand/megawattHour, year.getSolarFraction()*100)
+		fmt.Printf("  lid loss %.1f MWh, ground loss %.1f MWh, stored %+.1f MWh, storage efficiency %.1f%%, residual %.3f MWh\n",
+			year.lidLoss/megawattHour, year.groundLoss/megawattHour, year.storedChange/megawattHour,
+			year.getStorageEfficiency()*100, year.getResidual()/megawattHour)
+	}
+	plotResults(sim, result, "Pit storage layer temperatures over time, from the top down")
+	fmt.Println("Complete.")
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCollectorField(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	irradiance, storeTemp := 800.0, 50.0
+	field := &collectorField{
+		name: "CollectorField",
+		absorption: heatAborptionComponent{
+			efficiency:        0.8,
+			incidentRadiation: func() float64 { return irradiance },
+			surfaceArea:       1000,
+		},
+		loss: collectorLossComponent{
+			a1:          2.5,
+			a2:          0.01,
+			surfaceArea: 1000,
+			currentTemp: mockVariableIntegrator(40),
+			ambientTemp: mockVariableIntegrator(20),
+		},
+		flowRate:  0.005,
+		inletTemp: mockVariableIntegrator(40),
+		fluid:     water,
+		storeTemp: func() float64 { return storeTemp },
+		maxTemp:   90,
+	}
+
+	// q = A(η₀G - a₁ΔT - a₂ΔT²) = 1000(640 - 50 - 4) W
+	field.update(0, 3600)
+	heat := 1000 * (0.8*800 - 2.5*20 - 0.01*400)
+	if field.getFlowMass() != 5 {
+		t.Errorf("expected 5 kg/s, got %v kg/s", field.getFlowMass())
+	}
+	if math.Abs(field.getOutletTemp()-(40+heat/(5*water.getSpecificHeat(40)))) > 1e-9 {
+		t.Errorf("unexpected outlet temperature %v °C", field.getOutletTemp())
+	}
+	if math.Abs(field.collectedEnergy-heat*3600) > 1e-6 {
+		t.Errorf("expected %v J collected, got %v J", heat*3600, field.collectedEnergy)
+	}
+
+	// the pumps stop without a gain, and once the storage is full
+	irradiance = 50
+	field.update(3600, 3600)
+	if field.getFlowMass() != 0 || field.getOutletTemp() != 40 {
+		t.Errorf("expected the field to stop at night, got %v kg/s", field.getFlowMass())
+	}
+	irradiance, storeTemp = 800, 90
+	field.update(7200, 3600)
+	if field.getFlowMass() != 0 || math.Abs(field.collectedEnergy-heat*3600) > 1e-6 {
+		t.Errorf("expected the field to stop with a full storage, got %v kg/s", field.getFlowMass())
+	}
+}
+
+func TestAnnualEnergyBalance(t *testing.T) {
+	collected, stored := 0.0, 100.0
+	balance := &annualEnergyBalance{
+		name:            "EnergyBalance",
+		collectedEnergy: func() float64 { return collected },
+		demandEnergy:    func() float64 { return 0.0 },
+		deliveredEnergy: func() float64 { return 0.0 },
+		storedEnergy:    func() float64 { return stored },
+		lidLosses:       []IHeatComponent{heatRateComponent{heat: mockVariableIntegrator(1)}},
+	}
+	balance.startYear()
+
+	// a year and a half of daily steps, collecting 10 J a day, losing 1 W through the lid and storing the rest
+	day := 24 * 60 * 60.0
+	for i := range 365 * 3 / 2 {
+		balance.update(float64(i)*day, day)
+		collected += 10
+		stored += 10 - day
+	}
+	balance.finish()
+
+	if len(balance.years) != 2 {
+		t.Fatalf("expected a year and a partial year, got %v", balance.years)
+	}
+	first, second := balance.years[0], balance.years[1]
+	if first.duration != yearLength || first.collected != 3650 || first.lidLoss != 365*day {
+		t.Errorf("unexpected first year %+v", first)
+	}
+	if math.Abs(first.getResidual()) > 1e-6 || math.Abs(second.getResidual()) > 1e-6 {
+		t.Errorf("expected the balance to close, got residuals %v J and %v J", first.getResidual(), second.getResidual())
+	}
+	if second.duration != 182*day {
+		t.Errorf("expected a partial year of 182 days, got %v s", second.duration)
+	}
+
+	// the balance continues from a checkpoint
+	restored := &annualEnergyBalance{}
+	if err := restored.setState(balance.getState()); err != nil {
+		t.Fatal(err)
+	}
+	if len(restored.years) != 2 || restored.years[1] != second {
+		t.Errorf("expected the years to be restored, got %v", restored.years)
+	}
+}
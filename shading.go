@@ -0,0 +1,162 @@
+// shading: the horizon and nearby obstructions, like chimneys, trees and neighbouring buildings, seen from the panel.
+// They block the beam radiation while the sun is behind them, and block part of the sky's diffuse radiation.
+// Azimuths are in degrees from south, positive towards west, and elevations in degrees above the horizontal.
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"sort"
+	"strconv"
+)
+
+// horizonProfile is the elevation of the horizon by azimuth, interpolated linearly all the way round
+type horizonProfile struct {
+	azimuths   []float64 // ascending, within [-180, 180)
+	elevations []float64
+}
+
+func (h horizonProfile) at(azimuth float64) float64 {
+	n := len(h.azimuths)
+	if n == 0 {
+		return 0.0
+	}
+	azimuth = wrapAzimuth(azimuth)
+	i := sort.SearchFloat64s(h.azimuths, azimuth)
+	if i < n && h.azimuths[i] == azimuth {
+		return h.elevations[i]
+	}
+	lower, upper := i-1, i
+	lowerAzimuth, upperAzimuth := 0.0, 0.0
+	if i == 0 || i == n {
+		// between the last and first points, the profile wraps past ±180°
+		lower, upper = n-1, 0
+		lowerAzimuth, upperAzimuth = h.azimuths[n-1], h.azimuths[0]+360
+		if azimuth < h.azimuths[0] {
+			azimuth += 360
+		}
+	} else {
+		lowerAzimuth, upperAzimuth = h.azimuths[lower], h.azimuths[upper]
+	}
+	if upperAzimuth == lowerAzimuth {
+		return h.elevations[lower]
+	}
+	fraction := (azimuth - lowerAzimuth) / (upperAzimuth - lowerAzimuth)
+	return h.elevations[lower] + fraction*(h.elevations[upper]-h.elevations[lower])
+}
+
+// wrapAzimuth returns the same direction within [-180, 180)
+func wrapAzimuth(azimuth float64) float64 {
+	return clockwiseAngle(-180, azimuth) - 180
+}
+
+// clockwiseAngle is the angle within [0, 360) from one azimuth round to another
+func clockwiseAngle(from float64, to float64) float64 {
+	return math.Mod(math.Mod(to-from, 360)+360, 360)
+}
+
+// loadHorizonCSV reads a horizon profile from rows of azimuth,elevation. A header row is skipped.
+func loadHorizonCSV(fileName string) (horizonProfile, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return horizonProfile{}, err
+	}
+	defer f.Close()
+
+	rows, err := csv.NewReader(f).ReadAll()
+	if err != nil {
+		return horizonProfile{}, err
+	}
+
+	points := [][2]float64{}
+	for i, row := range rows {
+		if len(row) != 2 {
+			return horizonProfile{}, fmt.Errorf("%s line %d: expected azimuth,elevation", fileName, i+1)
+		}
+		azimuth, azimuthErr := strconv.ParseFloat(row[0], 64)
+		elevation, elevationErr := strconv.ParseFloat(row[1], 64)
+		if azimuthErr != nil || elevationErr != nil {
+			if i == 0 {
+				continue
+			}
+			return horizonProfile{}, fmt.Errorf("%s line %d: could not parse %v", fileName, i+1, row)
+		}
+		points = append(points, [2]float64{wrapAzimuth(azimuth), elevation})
+	}
+	if len(points) == 0 {
+		return horizonProfile{}, errors.New(fileName + ": no horizon points")
+	}
+	sort.Slice(points, func(i, j int) bool { return points[i][0] < points[j][0] })
+
+	h := horizonProfile{}
+	for _, point := range points {
+		h.azimuths = append(h.azimuths, point[0])
+		h.elevations = append(h.elevations, point[1])
+	}
+	return h, nil
+}
+
+// obstruction is a fixed shape in front of the panel, covering a range of azimuths between two elevations
+type obstruction struct {
+	fromAzimuth float64
+	toAzimuth   float64 // clockwise from fromAzimuth, so an obstruction can span north
+	bottom      float64
+	top         float64
+}
+
+func (o obstruction) covers(azimuth float64, elevation float64) bool {
+	inRange := clockwiseAngle(o.fromAzimuth, azimuth) <= clockwiseAngle(o.fromAzimuth, o.toAzimuth)
+	return inRange && elevation >= o.bottom && elevation <= o.top
+}
+
+type skyShading struct {
+	horizon      horizonProfile
+	obstructions []obstruction
+}
+
+// isBlocked is whether the direction is behind the horizon or an obstruction
+func (s skyShading) isBlocked(azimuth float64, elevation float64) bool {
+	if elevation <= s.horizon.at(azimuth) {
+		return true
+	}
+	for _, o := range s.obstructions {
+		if o.covers(azimuth, elevation) {
+			return true
+		}
+	}
+	return false
+}
+
+// isShaded is whether the sun's beam is blocked
+func (s skyShading) isShaded(sun sunPosition) bool {
+	return s.isBlocked(sun.azimuth, sun.altitude)
+}
+
+// skyViewFactor is the fraction of the isotropic diffuse radiation on a surface that isn't blocked,
+// integrating the sky's radiance weighted by cos θ over the visible sky
+func (s skyShading) skyViewFactor(tilt float64, surfaceAzimuth float64) float64 {
+	const step = 1.0 // degrees
+	visible, total := 0.0, 0.0
+	for azimuth := -180 + step/2; azimuth < 180; azimuth += step {
+		for elevation := step / 2; elevation < 90; elevation += step {
+			incidence := getIncidenceAngles(sunPosition{altitude: elevation, azimuth: azimuth}, tilt, surfaceAzimuth)
+			cosIncidence := math.Cos(incidence.angle * degreesToRadians)
+			if cosIncidence <= 0 {
+				continue
+			}
+			// dΩ = cos(elevation)·dα·dε
+			weight := cosIncidence * math.Cos(elevation*degreesToRadians)
+			total += weight
+			if !s.isBlocked(azimuth, elevation) {
+				visible += weight
+			}
+		}
+	}
+	if total == 0 {
+		return 0.0
+	}
+	return visible / total
+}
@@ -0,0 +1,85 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestHorizonProfile(t *testing.T) {
+	h := horizonProfile{azimuths: []float64{-90, 0, 90}, elevations: []float64{10, 20, 30}}
+	tests := []struct {
+		name     string
+		azimuth  float64
+		expected float64
+	}{
+		{name: "First point", azimuth: -90, expected: 10},
+		{name: "Point", azimuth: 0, expected: 20},
+		{name: "Last point", azimuth: 90, expected: 30},
+		{name: "Between points", azimuth: 45, expected: 25},
+		// the profile wraps from 90° round through north to -90°
+		{name: "Across north", azimuth: 180, expected: 20},
+		{name: "Across north, negative", azimuth: -135, expected: 15},
+		{name: "Beyond 180", azimuth: 225, expected: 15},
+		{name: "Just past 180", azimuth: 180.5, expected: 30 - 20*90.5/180},
+		{name: "Just past -180", azimuth: -180.5, expected: 30 - 20*89.5/180},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if elevation := h.at(tt.azimuth); math.Abs(elevation-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected %v, got %v", tt.expected, elevation)
+			}
+		})
+	}
+}
+
+func TestLoadHorizonCSV(t *testing.T) {
+	fileName := filepath.Join(t.TempDir(), "horizon.csv")
+	if err := os.WriteFile(fileName, []byte("azimuth,elevation\n90,30\n-90,10\n0,20\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	h, err := loadHorizonCSV(fileName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if elevation := h.at(-45); math.Abs(elevation-15) > float64EqualityThreshold {
+		t.Errorf("expected 15, got %v", elevation)
+	}
+}
+
+func TestParseObstructions(t *testing.T) {
+	obstructions, err := parseObstructions("-20:20:35, 170:-170:10:40")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(obstructions) != 2 {
+		t.Fatalf("expected 2 obstructions, got %v", len(obstructions))
+	}
+	if !obstructions[0].covers(0, 30) || obstructions[0].covers(30, 30) || obstructions[0].covers(0, 40) {
+		t.Error("unexpected coverage for the first obstruction")
+	}
+	// the second obstruction spans north, above the ground
+	if !obstructions[1].covers(180, 20) || !obstructions[1].covers(-175, 20) || obstructions[1].covers(180, 5) {
+		t.Error("unexpected coverage for the second obstruction")
+	}
+	if _, err := parseObstructions("0:10"); err == nil {
+		t.Error("expected error for a missing elevation")
+	}
+}
+
+func TestSkyShading(t *testing.T) {
+	shading := skyShading{obstructions: []obstruction{{fromAzimuth: -20, toAzimuth: 20, top: 80}}}
+	if !shading.isShaded(sunPosition{altitude: 60, azimuth: 0}) || shading.isShaded(sunPosition{altitude: 60, azimuth: 40}) {
+		t.Error("expected only the sun behind the obstruction to be shaded")
+	}
+
+	if svf := (skyShading{}).skyViewFactor(30, 0); math.Abs(svf-1) > float64EqualityThreshold {
+		t.Errorf("expected an open sky, got %v", svf)
+	}
+	// a horizontal surface under a uniform horizon at h sees cos²h of the diffuse radiation
+	horizon := skyShading{horizon: horizonProfile{azimuths: []float64{0}, elevations: []float64{30}}}
+	if svf := horizon.skyViewFactor(0, 0); math.Abs(svf-0.75) > 1e-3 {
+		t.Errorf("expected 0.75, got %v", svf)
+	}
+}
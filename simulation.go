@@ -0,0 +1,216 @@
+// the simulation runs the reset/step/commit cycle of its systems until the duration is reached, a stop condition triggers
+// or its context is cancelled
+package main
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/go-echarts/go-echarts/v2/opts"
+)
+
+const (
+	durationReachedReason = "duration reached"
+	progressInterval      = 100 * time.Millisecond // minimum wall-clock time between progress callbacks
+)
+
+// IController updates control state at the start of every step, before the systems step,
+// e.g. a pump's operating point or a thermostat
+type IController interface {
+	getName() string
+	update(time float64, timeStep float64)
+}
+
+// IWarningController is a controller that raises warnings during update
+type IWarningController interface {
+	IController
+	popWarnings() []string
+}
+
+type simulation struct {
+	systems        []ISystem
+	controllers    []IController // updated in order
+	timeStep       float64       // s
+	duration       float64       // s
+	currentTime    float64       // s; simulated time of the step being run
+	stopConditions []ICondition
+	events         []ICondition
+	onProgress     func(progress simulationProgress) // optional
+	// checkpoints are written every checkpointInterval of simulated time; 0 disables checkpoints
+	checkpointInterval float64 // s
+	checkpointFile     string
+	resumeFrom         *checkpoint // set by restoreCheckpoint
+}
+
+type simulationProgress struct {
+	time           float64 // s; simulated time
+	percent        float64
+	stepsPerSecond float64 // steps per wall-clock second
+}
+
+type simulationEvent struct {
+	time    float64 // s; simulated time the event triggered
+	message string
+}
+
+func (e simulationEvent) String() string {
+	return fmt.Sprintf("%s at %s", e.message, formatSimulatedTime(e.time))
+}
+
+type simulationResult struct {
+	timeSeries    []float64 // s; the start of the run, then the end of each step
+	systemsSeries map[string][]opts.LineData
+	stopReason    string
+	stopTime      float64 // s; simulated time at the end of the last step
+	events        []simulationEvent
+	warnings      []simulationEvent
+}
+
+// run runs the simulation. If ctx is cancelled or its deadline passes, run returns the partial results so far.
+func (sim *simulation) run(ctx context.Context) simulationResult {
+	simulationRuntime := int(sim.duration / sim.timeStep)
+
+	// setup plot arrays
+	result := simulationResult{
+		timeSeries:    make([]float64, 0, simulationRuntime),
+		systemsSeries: make(map[string][]opts.LineData),
+	}
+	for _, sys := range sim.systems {
+		result.systemsSeries[sys.getName()] = make([]opts.LineData, 0, simulationRuntime)
+	}
+
+	// a resumed run continues the recorded series from the step after the checkpoint
+	firstStepTime := 0.0
+	if sim.resumeFrom != nil {
+		firstStepTime = sim.resumeFrom.Time
+		result.stopTime = sim.resumeFrom.Time
+		result.timeSeries = append(result.timeSeries, sim.resumeFrom.TimeSeries...)
+		for _, sysCheckpoint := range sim.resumeFrom.Systems {
+			result.systemsSeries[sysCheckpoint.Name] = append(result.systemsSeries[sysCheckpoint.Name], floatsToLineData(sysCheckpoint.TemperatureSeries)...)
+		}
+	} else {
+		// the temperatures are recorded from the start of the run, so each step adds the point at its end
+		result.timeSeries = append(result.timeSeries, firstStepTime)
+		for _, sys := range sim.systems {
+			result.systemsSeries[sys.getName()] = append(result.systemsSeries[sys.getName()], opts.LineData{Value: sys.getTemp()})
+		}
+	}
+	nextCheckpoint := math.Inf(1)
+	if sim.checkpointInterval > 0 {
+		nextCheckpoint = (math.Floor(firstStepTime/sim.checkpointInterval+float64EqualityThreshold) + 1) * sim.checkpointInterval
+	}
+
+	// events only trigger once, stop conditions end the run
+	startTime := time.Now()
+	lastProgress := startTime
+	steps := 0
+	firedEvents := make([]bool, len(sim.events))
+	state := simulationState{
+		systems:       sim.systems,
+		timeStep:      sim.timeStep,
+		previousTemps: make([]float64, len(sim.systems)),
+	}
+	// each step runs from currentTime to stepEnd, and what happened during it is stamped with stepEnd.
+	// The last step ends at the duration; half a step of margin absorbs rounding in long runs.
+	for sim.currentTime = firstStepTime; sim.currentTime+sim.timeStep < sim.duration+sim.timeStep/2; sim.currentTime += sim.timeStep {
+		stepEnd := sim.currentTime + sim.timeStep
+		select {
+		case <-ctx.Done():
+			result.stopReason = "cancelled: " + ctx.Err().Error()
+			sim.reportProgress(result.stopTime, steps, startTime)
+			return result
+		default:
+		}
+
+		for i, sys := range sim.systems {
+			state.previousTemps[i] = sys.getTemp()
+		}
+
+		result.timeSeries = append(result.timeSeries, stepEnd)
+		for _, controller := range sim.controllers {
+			controller.update(sim.currentTime, sim.timeStep)
+			if warningController, ok := controller.(IWarningController); ok {
+				for _, warning := range warningController.popWarnings() {
+					result.warnings = append(result.warnings, simulationEvent{time: stepEnd, message: warning})
+				}
+			}
+		}
+		for _, sys := range sim.systems {
+			sys.reset()
+		}
+		for _, sys := range sim.systems {
+			sys.step()
+		}
+		for _, sys := range sim.systems {
+			sys.commit(sim.timeStep)
+			result.systemsSeries[sys.getName()] = append(result.systemsSeries[sys.getName()], opts.LineData{Value: sys.getTemp()})
+			if warningSys, ok := sys.(IWarningSystem); ok {
+				for _, warning := range warningSys.popWarnings() {
+					result.warnings = append(result.warnings, simulationEvent{time: stepEnd, message: warning})
+				}
+			}
+		}
+
+		steps++
+		result.stopTime = stepEnd
+		state.time = stepEnd
+		state.wallClockElapsed = time.Since(startTime)
+		if state.wallClockElapsed-lastProgress.Sub(startTime) >= progressInterval {
+			lastProgress = time.Now()
+			sim.reportProgress(stepEnd, steps, startTime)
+		}
+		for i, event := range sim.events {
+			if !firedEvents[i] && event.check(state) {
+				firedEvents[i] = true
+				result.events = append(result.events, simulationEvent{time: stepEnd, message: event.describe()})
+			}
+		}
+		if stepEnd >= nextCheckpoint-float64EqualityThreshold {
+			nextCheckpoint += sim.checkpointInterval
+			if err := sim.saveCheckpoint(result); err != nil {
+				result.events = append(result.events, simulationEvent{time: stepEnd, message: "checkpoint failed: " + err.Error()})
+			}
+		}
+		for _, condition := range sim.stopConditions {
+			if condition.check(state) {
+				result.stopReason = condition.describe()
+				sim.reportProgress(stepEnd, steps, startTime)
+				return result
+			}
+		}
+	}
+
+	result.stopReason = durationReachedReason
+	sim.reportProgress(result.stopTime, steps, startTime)
+	return result
+}
+
+func (sim *simulation) saveCheckpoint(result simulationResult) error {
+	cp, err := sim.createCheckpoint(result)
+	if err != nil {
+		return err
+	}
+	return writeCheckpoint(cp, sim.checkpointFile)
+}
+
+func (sim *simulation) reportProgress(simulatedTime float64, steps int, startTime time.Time) {
+	if sim.onProgress == nil {
+		return
+	}
+	progress := simulationProgress{
+		time:           simulatedTime,
+		percent:        100.0,
+		stepsPerSecond: float64(steps) / time.Since(startTime).Seconds(),
+	}
+	if sim.duration > 0 {
+		progress.percent = 100.0 * simulatedTime / sim.duration
+	}
+	sim.onProgress(progress)
+}
+
+// formatSimulatedTime formats seconds of simulated time, e.g. 2h13m0s
+func formatSimulatedTime(seconds float64) string {
+	return time.Duration(seconds * float64(time.Second)).Round(time.Second).String()
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestSimulation_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	fs := &fluidSystem{name: "System", fluidMass: 1.0, temperature: 20.0}
+	sim := simulation{
+		systems:  []ISystem{fs},
+		timeStep: 1.0,
+		duration: 100.0,
+		events: []ICondition{
+			&temperatureThresholdCondition{systemName: "System", threshold: 25.0},
+		},
+		stopConditions: []ICondition{
+			// cancel partway through the run rather than stopping it
+			&cancellingCondition{cancel: cancel, after: 10},
+		},
+	}
+
+	result := sim.run(ctx)
+	if result.stopReason != "cancelled: context canceled" {
+		t.Errorf("unexpected stop reason %q", result.stopReason)
+	}
+	// the starting point and one point per step
+	if len(result.timeSeries) != 11 {
+		t.Errorf("expected partial results with 10 steps, got %v points", len(result.timeSeries))
+	}
+	if len(result.systemsSeries["System"]) != 11 {
+		t.Errorf("expected 11 temperatures, got %v", len(result.systemsSeries["System"]))
+	}
+}
+
+func TestSimulation_Progress(t *testing.T) {
+	fs := &fluidSystem{name: "System", fluidMass: 1.0, temperature: 20.0}
+	var last simulationProgress
+	calls := 0
+	sim := simulation{
+		systems:  []ISystem{fs},
+		timeStep: 1.0,
+		duration: 100.0,
+		onProgress: func(progress simulationProgress) {
+			calls++
+			last = progress
+		},
+	}
+
+	sim.run(context.Background())
+	if calls == 0 {
+		t.Fatal("expected at least one progress callback")
+	}
+	if last.percent != 100.0 || last.time != 100.0 {
+		t.Errorf("expected final progress at 100%% and 100s, got %v%% and %vs", last.percent, last.time)
+	}
+}
+
+type cancellingCondition struct {
+	cancel context.CancelFunc
+	after  int
+	checks int
+}
+
+func (c *cancellingCondition) check(state simulationState) bool {
+	c.checks++
+	if c.checks == c.after {
+		c.cancel()
+	}
+	return false
+}
+
+func (c *cancellingCondition) describe() string {
+	return "cancelling condition"
+}
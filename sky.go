@@ -0,0 +1,35 @@
+// sky temperature models for long-wave radiation loss.
+// The effective sky temperature is the temperature of a black body that emits the same long-wave radiation as the sky.
+package main
+
+import "math"
+
+const (
+	swinbankSkyModel      = "swinbank"
+	berdahlMartinSkyModel = "berdahl-martin"
+)
+
+// swinbankSkyTemp is the clear sky temperature from air temperature alone: Tₛₖᵧ = 0.0552·Tₐ^1.5, in Kelvin
+func swinbankSkyTemp(airTemp float64) float64 {
+	return 0.0552*math.Pow(airTemp+celsiusToKelvin, 1.5) - celsiusToKelvin
+}
+
+// berdahlMartinSkyTemp uses the dew point, cloud cover (0 to 1) and hour of the day to compute the sky emissivity,
+// then Tₛₖᵧ = ε^0.25·Tₐ, in Kelvin
+func berdahlMartinSkyTemp(airTemp float64, dewPoint float64, cloudCover float64, hour float64) float64 {
+	// clear sky emissivity, with a small correction for the daily cycle
+	dp := dewPoint / 100
+	clearEmissivity := 0.711 + 0.56*dp + 0.73*dp*dp + 0.013*math.Cos(2*math.Pi*hour/24)
+
+	// cloud cover correction, where n is the cloud cover in tenths
+	n := 10 * math.Max(0, math.Min(1, cloudCover))
+	emissivity := clearEmissivity * (1 + 0.0224*n - 0.0035*n*n + 0.00028*n*n*n)
+	emissivity = math.Min(1, emissivity)
+
+	return math.Pow(emissivity, 0.25)*(airTemp+celsiusToKelvin) - celsiusToKelvin
+}
+
+// tiltedSkyViewFactor is the fraction of a tilted surface's view taken up by the sky
+func tiltedSkyViewFactor(tilt float64) float64 {
+	return (1 + math.Cos(tilt*math.Pi/180)) / 2
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSwinbankSkyTemp(t *testing.T) {
+	expected := 0.0552*math.Pow(293.15, 1.5) - celsiusToKelvin
+	if temp := swinbankSkyTemp(20.0); math.Abs(temp-expected) > 1e-9 {
+		t.Errorf("expected %v, got %v", expected, temp)
+	}
+}
+
+func TestBerdahlMartinSkyTemp(t *testing.T) {
+	clear := berdahlMartinSkyTemp(20.0, 10.0, 0.0, 6.0)
+	// at 6:00 the daily correction is zero: ε = 0.711 + 0.56·0.1 + 0.73·0.01
+	expected := math.Pow(0.7743, 0.25)*293.15 - celsiusToKelvin
+	if math.Abs(clear-expected) > 1e-9 {
+		t.Errorf("expected %v, got %v", expected, clear)
+	}
+	if clear >= 20.0 {
+		t.Errorf("expected clear sky to be colder than the air, got %v", clear)
+	}
+
+	overcast := berdahlMartinSkyTemp(20.0, 10.0, 1.0, 6.0)
+	if overcast <= clear {
+		t.Errorf("expected overcast sky %v to be warmer than clear sky %v", overcast, clear)
+	}
+}
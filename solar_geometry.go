@@ -0,0 +1,73 @@
+// solar geometry: the sun's position through the day and year, and the angle its beam makes with a tilted collector.
+// Directions are vectors of east, north and up components, and azimuths are measured from south, positive towards west.
+package main
+
+import "math"
+
+const degreesToRadians = math.Pi / 180
+
+// sunPosition is the sun's direction in degrees
+type sunPosition struct {
+	altitude float64 // above the horizon
+	azimuth  float64 // from south, positive towards west
+}
+
+// getSunPosition uses Cooper's declination and the hour angle at a solar time, ignoring the equation of time
+func getSunPosition(dayOfYear float64, solarHour float64, latitude float64) sunPosition {
+	// δ = 23.45°·sin(360°(284 + n)/365)
+	declination := 23.45 * degreesToRadians * math.Sin(2*math.Pi*(284+dayOfYear)/365)
+	// ω = 15°(h - 12)
+	hourAngle := 15 * degreesToRadians * (solarHour - 12)
+	phi := latitude * degreesToRadians
+
+	east := -math.Cos(declination) * math.Sin(hourAngle)
+	north := math.Sin(declination)*math.Cos(phi) - math.Cos(declination)*math.Cos(hourAngle)*math.Sin(phi)
+	up := math.Sin(declination)*math.Sin(phi) + math.Cos(declination)*math.Cos(hourAngle)*math.Cos(phi)
+	return sunPosition{
+		altitude: math.Asin(math.Max(-1, math.Min(1, up))) / degreesToRadians,
+		azimuth:  math.Atan2(-east, -north) / degreesToRadians,
+	}
+}
+
+// vector is the unit vector towards the sun
+func (s sunPosition) vector() (east float64, north float64, up float64) {
+	altitude := s.altitude * degreesToRadians
+	azimuth := s.azimuth * degreesToRadians
+	return -math.Cos(altitude) * math.Sin(azimuth), -math.Cos(altitude) * math.Cos(azimuth), math.Sin(altitude)
+}
+
+// incidenceAngles are the angles in degrees between the sun's beam and a collector's normal.
+// The longitudinal and transverse angles are projected onto the planes along and across the collector's slope,
+// which is the direction of an evacuated tube.
+type incidenceAngles struct {
+	angle        float64
+	longitudinal float64
+	transverse   float64
+}
+
+// normalIncidence is the beam hitting the collector straight on
+var normalIncidence = incidenceAngles{}
+
+// getIncidenceAngles returns the incidence angles on a surface with a tilt from horizontal and an azimuth.
+// A sun behind the surface has an angle of at least 90°.
+func getIncidenceAngles(sun sunPosition, tilt float64, surfaceAzimuth float64) incidenceAngles {
+	beta := tilt * degreesToRadians
+	gamma := surfaceAzimuth * degreesToRadians
+	east, north, up := sun.vector()
+
+	// the surface normal, the direction up the slope, and the direction across the slope
+	normal := east*(-math.Sin(beta)*math.Sin(gamma)) + north*(-math.Sin(beta)*math.Cos(gamma)) + up*math.Cos(beta)
+	along := east*(math.Cos(beta)*math.Sin(gamma)) + north*(math.Cos(beta)*math.Cos(gamma)) + up*math.Sin(beta)
+	across := east*math.Cos(gamma) - north*math.Sin(gamma)
+	return incidenceAngles{
+		angle:        math.Acos(math.Max(-1, math.Min(1, normal))) / degreesToRadians,
+		longitudinal: math.Abs(math.Atan2(along, normal)) / degreesToRadians,
+		transverse:   math.Abs(math.Atan2(across, normal)) / degreesToRadians,
+	}
+}
+
+// diffuseIncidenceAngle is the single beam angle that transmits the same as isotropic sky diffuse radiation
+// on a surface with a tilt, from Brandemuehl and Beckman
+func diffuseIncidenceAngle(tilt float64) float64 {
+	return 59.7 - 0.1388*tilt + 0.001497*tilt*tilt
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestGetSunPosition(t *testing.T) {
+	tests := []struct {
+		name     string
+		day      float64
+		hour     float64
+		latitude float64
+		altitude float64
+		azimuth  float64
+	}{
+		// the declination is close to zero at the March equinox, so the noon sun is 90° - latitude high
+		{name: "Equinox noon", day: 81, hour: 12, latitude: 45, altitude: 45, azimuth: 0},
+		{name: "Summer solstice noon", day: 172, hour: 12, latitude: 45, altitude: 68.45, azimuth: 0},
+		{name: "Equinox sunset", day: 81, hour: 18, latitude: 45, altitude: 0, azimuth: 90},
+		{name: "Equinox morning", day: 81, hour: 6, latitude: 45, altitude: 0, azimuth: -90},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sun := getSunPosition(tt.day, tt.hour, tt.latitude)
+			if math.Abs(sun.altitude-tt.altitude) > 0.1 || math.Abs(sun.azimuth-tt.azimuth) > 0.1 {
+				t.Errorf("expected altitude %v and azimuth %v, got %v and %v", tt.altitude, tt.azimuth, sun.altitude, sun.azimuth)
+			}
+		})
+	}
+}
+
+func TestGetIncidenceAngles(t *testing.T) {
+	// a sun straight along the collector's normal
+	incidence := getIncidenceAngles(sunPosition{altitude: 60, azimuth: 0}, 30, 0)
+	if incidence.angle > 1e-6 || incidence.longitudinal > 1e-6 || incidence.transverse > 1e-6 {
+		t.Errorf("expected normal incidence, got %+v", incidence)
+	}
+
+	// a sun moving up the slope only changes the longitudinal angle
+	incidence = getIncidenceAngles(sunPosition{altitude: 80, azimuth: 0}, 30, 0)
+	if math.Abs(incidence.angle-20) > 1e-6 || math.Abs(incidence.longitudinal-20) > 1e-6 || incidence.transverse > 1e-6 {
+		t.Errorf("expected a 20° longitudinal angle, got %+v", incidence)
+	}
+
+	// a sun across the slope of a horizontal collector only changes the transverse angle
+	incidence = getIncidenceAngles(sunPosition{altitude: 50, azimuth: 90}, 0, 0)
+	if math.Abs(incidence.angle-40) > 1e-6 || incidence.longitudinal > 1e-6 || math.Abs(incidence.transverse-40) > 1e-6 {
+		t.Errorf("expected a 40° transverse angle, got %+v", incidence)
+	}
+
+	// a sun behind the collector
+	incidence = getIncidenceAngles(sunPosition{altitude: 10, azimuth: 180}, 30, 0)
+	if incidence.angle < 90 {
+		t.Errorf("expected the sun behind the collector, got %+v", incidence)
+	}
+}
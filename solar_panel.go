@@ -3,27 +3,128 @@ package main
 
 type solarPanel struct {
 	fluidSystem
-	panelArea       float64
-	panelEfficiency float64
-	solarIrradiance float64
+	panelArea         float64
+	panelEfficiency   float64
+	solarIrradiance   float64                // W/m^2; in the panel's plane, used when beamIrradiance isn't set
+	beamIrradiance    variableIntegrator     // optional; W/m^2 in the panel's plane
+	diffuseIrradiance variableIntegrator     // optional; W/m^2 in the panel's plane
+	incidence         func() incidenceAngles // optional; the beam's angles, at normal incidence when not set
+	tilt              float64                // degrees from horizontal
+	absorber          *absorberPlate         // optional; when set, the absorber receives the radiation and loses heat to ambient
+	pv                *pvCells               // optional; when set, the panel is a PVT collector
+	tubes             *evacuatedTubes        // optional; when set, the panel is an evacuated tube collector
+	surface           *surfaceCondition      // optional; snow and soiling on the panel's cover
 }
 
-func (sp *solarPanel) initialize(fluidOutputs []IFluidSystem, flowRate float64) {
+func (sp *solarPanel) initialize(fluidOutputs []IFluidSystem, flowRate variableIntegrator) {
+	if sp.beamIrradiance == nil {
+		sp.beamIrradiance = func() float64 { return (*sp).solarIrradiance }
+		sp.diffuseIrradiance = func() float64 { return 0.0 }
+	}
+	if sp.incidence == nil {
+		sp.incidence = func() incidenceAngles { return normalIncidence }
+	}
+	if sp.surface != nil {
+		// snow and dirt on the cover block the radiation before it reaches the absorber
+		beamIrradiance, diffuseIrradiance := sp.beamIrradiance, sp.diffuseIrradiance
+		sp.surface.irradiance = func() float64 { return beamIrradiance() + diffuseIrradiance() }
+		sp.beamIrradiance = func() float64 { return sp.surface.getTransmittance() * beamIrradiance() }
+		sp.diffuseIrradiance = func() float64 { return sp.surface.getTransmittance() * diffuseIrradiance() }
+	}
+	incidentRadiation := func() float64 { return sp.beamIrradiance() + sp.diffuseIrradiance() }
+
 	// include all the power components involved in this system
-	sp.heatInComponents = []IComponent{
-		heatAborptionComponent{
+	sp.heatInComponents = []IComponent{}
+	sp.heatOutComponents = []IComponent{}
+	if sp.absorber != nil {
+		sp.absorber.initialize(&sp.fluidSystem, incidentRadiation)
+	} else if sp.tubes != nil {
+		// the vacuum insulates the absorber from the wind, so the loss coefficients replace convection
+		sp.heatInComponents = append(sp.heatInComponents, iamAbsorptionComponent{
+			component: component{
+				name: "Incident Radiation",
+			},
+			efficiency:        sp.panelEfficiency,
+			iam:               sp.tubes.iam,
+			beamIrradiance:    sp.beamIrradiance,
+			diffuseIrradiance: sp.diffuseIrradiance,
+			incidence:         sp.incidence,
+			diffuseAngle:      diffuseIncidenceAngle(sp.tilt),
+			surfaceArea:       sp.panelArea,
+		})
+		sp.heatOutComponents = append(sp.heatOutComponents, collectorLossComponent{
+			component: component{
+				name: "Collector Heat Loss",
+			},
+			a1:          sp.tubes.a1,
+			a2:          sp.tubes.a2,
+			surfaceArea: sp.panelArea,
+			currentTemp: func() float64 { return (*sp).temperature },
+			ambientTemp: func() float64 { return (*sp).getAmbientTemp() },
+		})
+	} else {
+		sp.heatInComponents = append(sp.heatInComponents, heatAborptionComponent{
 			component: component{
 				name: "Incident Radiation",
 			},
 			efficiency:        sp.panelEfficiency,
-			incidentRadiation: func() float64 { return (*sp).solarIrradiance },
+			incidentRadiation: incidentRadiation,
 			surfaceArea:       sp.panelArea,
-		},
+		})
+		sp.addEnvironmentalConvectionHeatLossComponent()
 	}
-
-	sp.heatOutComponents = []IComponent{}
-	sp.addEnvironmentalConvectionHeatLossComponent()
 	for _, output := range fluidOutputs {
 		sp.addOutputHeatFluidComponent(output, flowRate)
 	}
+	if sp.pv != nil {
+		// the cells are at the temperature of the surface receiving the radiation
+		surface := &sp.fluidSystem
+		if sp.absorber != nil {
+			surface = &sp.absorber.fluidSystem
+		}
+		sp.pv.name = "Electrical Output"
+		sp.pv.surfaceArea = sp.panelArea
+		sp.pv.incidentRadiation = incidentRadiation
+		sp.pv.cellTemp = func() float64 { return surface.temperature }
+		surface.heatOutComponents = append(surface.heatOutComponents, sp.pv)
+	}
+	if sp.surface != nil {
+		// the snow melts with heat from the surface it lies on
+		surface := &sp.fluidSystem
+		if sp.absorber != nil {
+			surface = &sp.absorber.fluidSystem
+		}
+		sp.surface.panelTemp = func() float64 { return surface.temperature }
+		surface.heatOutComponents = append(surface.heatOutComponents, heatRateComponent{
+			component: component{
+				name: "Snow Melt",
+			},
+			heat: sp.surface.getMeltHeat,
+		})
+	}
+}
+
+func (sp *solarPanel) commit(timeStep float64) {
+	if sp.pv != nil {
+		sp.pv.commit(timeStep)
+	}
+	sp.fluidSystem.commit(timeStep)
+}
+
+// getState adds the state of a PVT panel's cells to the fluid's
+func (sp *solarPanel) getState() map[string]float64 {
+	state := sp.fluidSystem.getState()
+	if sp.pv != nil {
+		for key, value := range sp.pv.getState() {
+			state[key] = value
+		}
+	}
+	return state
+}
+
+func (sp *solarPanel) setState(state map[string]float64) error {
+	if sp.pv != nil {
+		sp.pv.setState(state)
+	}
+	return sp.fluidSystem.setState(state)
 }
@@ -0,0 +1,134 @@
+// space heating: a radiant floor or radiator loop heats the building from the storage tank.
+// The building's heat demand scales with the outdoor temperature from its design heat loss,
+// and an outdoor reset curve sets the loop's supply temperature. A mixing valve blends tank water
+// into the loop's return to reach the supply temperature, and whatever the tank can't cover is left to a backup heater.
+package main
+
+import "math"
+
+const (
+	radiantFloorEmitter = "radiant-floor"
+	radiatorEmitter     = "radiators"
+)
+
+// heatEmitter describes how a heating loop's output falls as its supply temperature drops
+type heatEmitter struct {
+	designSupplyTemp float64 // Celsius; supply temperature at the design outdoor temperature
+	maxSupplyTemp    float64 // Celsius
+	designDeltaT     float64 // K; supply to return difference at the design heat loss
+	exponent         float64 // Q ∝ (Tₛ - Tᵢₙ)ⁿ
+}
+
+var heatEmitters = map[string]heatEmitter{
+	// floors are limited to a low supply temperature to keep the surface comfortable
+	radiantFloorEmitter: {designSupplyTemp: 35, maxSupplyTemp: 45, designDeltaT: 5, exponent: 1.1},
+	radiatorEmitter:     {designSupplyTemp: 60, maxSupplyTemp: 75, designDeltaT: 10, exponent: 1.3},
+}
+
+type spaceHeatingLoad struct {
+	name              string
+	emitter           heatEmitter
+	designHeatLoss    float64 // W; at designOutdoorTemp
+	designOutdoorTemp float64 // Celsius
+	indoorTemp        float64 // Celsius; no heating is needed at or above this outdoor temperature
+	minSupplyTemp     float64 // Celsius; lower limit of the reset curve
+	outdoorTemp       variableIntegrator
+	tankTemp          variableIntegrator
+	solarShare        variableIntegrator // optional; the fraction of the tank's heat that came from the collector
+	fluid             *fluid
+	// the loop's operating point, updated every step
+	loopFlowMass    float64 // kg/s; constant while there is demand
+	supplyTemp      float64 // Celsius
+	returnTemp      float64 // Celsius
+	tankFlowMass    float64 // kg/s; taken from the tank through the mixing valve
+	demandEnergy    float64 // J; total over the run
+	deliveredEnergy float64 // J; from the tank, total over the run
+	solarEnergy     float64 // J; the solar part of deliveredEnergy
+	dataRecorder
+}
+
+func (h *spaceHeatingLoad) getName() string {
+	return h.name
+}
+
+// getDemand is the building's heat loss at an outdoor temperature
+func (h *spaceHeatingLoad) getDemand(outdoorTemp float64) float64 {
+	return math.Max(0, h.designHeatLoss*(h.indoorTemp-outdoorTemp)/(h.indoorTemp-h.designOutdoorTemp))
+}
+
+// getResetTemp is the supply temperature from the outdoor reset curve, running from the indoor temperature
+// at the balance point to the emitter's design supply temperature at the design outdoor temperature
+func (h *spaceHeatingLoad) getResetTemp(outdoorTemp float64) float64 {
+	fraction := (h.indoorTemp - outdoorTemp) / (h.indoorTemp - h.designOutdoorTemp)
+	resetTemp := h.indoorTemp + fraction*(h.emitter.designSupplyTemp-h.indoorTemp)
+	return math.Max(h.minSupplyTemp, math.Min(h.emitter.maxSupplyTemp, resetTemp))
+}
+
+func (h *spaceHeatingLoad) update(time float64, timeStep float64) {
+	outdoorTemp := h.outdoorTemp()
+	tankTemp := h.tankTemp()
+	demand := h.getDemand(outdoorTemp)
+	h.loopFlowMass, h.tankFlowMass, h.supplyTemp, h.returnTemp = 0.0, 0.0, tankTemp, tankTemp
+	delivered := 0.0
+	if demand > 0 {
+		specificHeat := h.fluid.getSpecificHeat(tankTemp)
+		// the loop is sized to carry the design heat loss at the emitter's design ΔT: ṁ = Q/(CΔT)
+		h.loopFlowMass = h.designHeatLoss / (specificHeat * h.emitter.designDeltaT)
+		resetTemp := h.getResetTemp(outdoorTemp)
+		h.supplyTemp = math.Min(tankTemp, resetTemp)
+		// a tank below the reset temperature can only cover part of the demand: Q = Qd((Tₛ - Tᵢₙ)/(Tᵣ - Tᵢₙ))ⁿ
+		if h.supplyTemp > h.indoorTemp {
+			delivered = demand * math.Pow((h.supplyTemp-h.indoorTemp)/(resetTemp-h.indoorTemp), h.emitter.exponent)
+		}
+		h.returnTemp = h.supplyTemp - delivered/(h.loopFlowMass*specificHeat)
+		// the mixing valve takes the hot fraction of the loop flow from the tank, and the rest from the return
+		h.tankFlowMass = h.loopFlowMass * thermostaticFraction(h.supplyTemp, tankTemp, h.returnTemp)
+	}
+	h.demandEnergy += demand * timeStep
+	h.deliveredEnergy += delivered * timeStep
+	if h.solarShare != nil {
+		h.solarEnergy += delivered * h.solarShare() * timeStep
+	}
+
+	h.addDataPoint("Heating Demand", demand)
+	h.addDataPoint("Heat From Tank", delivered)
+	h.addDataPoint("Backup Heat", demand-delivered)
+	h.addDataPoint("Supply Temperature", h.supplyTemp)
+	h.addDataPoint("Return Temperature", h.returnTemp)
+}
+
+// getTankShare is the fraction of the heating demand covered by the tank over the run, whatever heated the tank
+func (h *spaceHeatingLoad) getTankShare() float64 {
+	if h.demandEnergy == 0 {
+		return 0.0
+	}
+	return h.deliveredEnergy / h.demandEnergy
+}
+
+// getSolarShare is the fraction of the heating demand covered by solar heat from the tank over the run
+func (h *spaceHeatingLoad) getSolarShare() float64 {
+	if h.demandEnergy == 0 {
+		return 0.0
+	}
+	return h.solarEnergy / h.demandEnergy
+}
+
+// connect returns the loop's water to the tank, in exchange for the hot water it takes
+func (h *spaceHeatingLoad) connect(tank *fluidSystem) {
+	tank.addInflowComponent("Space Heating Return", func() float64 { return h.tankFlowMass }, func() float64 { return h.returnTemp })
+}
+
+func (h *spaceHeatingLoad) getState() map[string]float64 {
+	return map[string]float64{
+		"demandEnergy":    h.demandEnergy,
+		"deliveredEnergy": h.deliveredEnergy,
+		"solarEnergy":     h.solarEnergy,
+	}
+}
+
+func (h *spaceHeatingLoad) setState(state map[string]float64) error {
+	h.demandEnergy = state["demandEnergy"]
+	h.deliveredEnergy = state["deliveredEnergy"]
+	h.solarEnergy = state["solarEnergy"]
+	return nil
+}
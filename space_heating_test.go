@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+func TestSpaceHeatingLoad(t *testing.T) {
+	water := fluids[waterFluidName]
+	tank := fluidSystem{name: "StorageTank", fluid: water, fluidMass: 300, temperature: 60}
+	h := spaceHeatingLoad{
+		name:              "SpaceHeating",
+		emitter:           heatEmitters[radiantFloorEmitter],
+		designHeatLoss:    5000,
+		designOutdoorTemp: -10,
+		indoorTemp:        20,
+		minSupplyTemp:     25,
+		outdoorTemp:       mockVariableIntegrator(5),
+		tankTemp:          func() float64 { return tank.temperature },
+		fluid:             water,
+	}
+	h.connect(&tank)
+
+	// halfway to the design temperature, the demand and the reset curve are halfway too
+	if demand := h.getDemand(5); math.Abs(demand-2500) > float64EqualityThreshold {
+		t.Errorf("expected 2500 W demand, got %v W", demand)
+	}
+	if resetTemp := h.getResetTemp(5); math.Abs(resetTemp-27.5) > float64EqualityThreshold {
+		t.Errorf("expected a 27.5 °C supply, got %v °C", resetTemp)
+	}
+	if resetTemp := h.getResetTemp(-30); resetTemp != 45 {
+		t.Errorf("expected the supply to be limited to 45 °C, got %v °C", resetTemp)
+	}
+
+	// a hot tank covers the whole demand, and loses it through the loop's return
+	h.update(0, 60)
+	tankHeat := tank.heatInComponents[0].(IHeatComponent).getHeat()
+	if math.Abs(tankHeat+2500) > 1e-6 {
+		t.Errorf("expected the tank to lose 2500 W, got %v W", -tankHeat)
+	}
+
+	// a tank below the supply temperature covers part of the demand
+	tank.temperature = 25
+	h.update(60, 60)
+	expected := 2500 * math.Pow(5.0/7.5, h.emitter.exponent)
+	tankHeat = tank.heatInComponents[0].(IHeatComponent).getHeat()
+	if math.Abs(tankHeat+expected) > 1e-6 {
+		t.Errorf("expected the tank to lose %v W, got %v W", expected, -tankHeat)
+	}
+	if share := h.getTankShare(); math.Abs(share-(2500+expected)/5000) > 1e-9 {
+		t.Errorf("unexpected tank share %v", share)
+	}
+}
+
+func TestSpaceHeatingSolarShare(t *testing.T) {
+	water := fluids[waterFluidName]
+	// the tank starts at the indoor temperature, and the collector and an auxiliary heater each add 2 kW
+	tank := &storageTank{fluidSystem: fluidSystem{
+		name:        "StorageTank",
+		fluid:       water,
+		fluidMass:   300,
+		temperature: 20,
+		heatInComponents: []IComponent{
+			mockHeatComponent{mockComponent: mockComponent{name: "Collector Inflow"}, heat: 2000},
+			mockHeatComponent{mockComponent: mockComponent{name: "Heater"}, heat: 2000},
+		},
+	}}
+	tank.sources = newTankHeatSources(&tank.fluidSystem, 20)
+	tank.sources.auxiliaryGain = func() float64 { return 2000 }
+	h := &spaceHeatingLoad{
+		name:              "SpaceHeating",
+		emitter:           heatEmitters[radiantFloorEmitter],
+		designHeatLoss:    5000,
+		designOutdoorTemp: -10,
+		indoorTemp:        20,
+		minSupplyTemp:     25,
+		outdoorTemp:       mockVariableIntegrator(5),
+		tankTemp:          func() float64 { return tank.temperature },
+		solarShare:        tank.sources.getSolarShare,
+		fluid:             water,
+	}
+	h.connect(&tank.fluidSystem)
+	sim := simulation{
+		systems:     []ISystem{tank},
+		controllers: []IController{h},
+		timeStep:    60,
+		duration:    2 * 60 * 60,
+	}
+	sim.run(context.Background())
+
+	// the heater's heat comes out of the tank alongside the collector's, so only half the tank's share is solar
+	if h.getTankShare() <= 0 || math.Abs(h.getSolarShare()-h.getTankShare()/2) > 1e-9 {
+		t.Errorf("expected a solar share of half the tank share %v, got %v", h.getTankShare(), h.getSolarShare())
+	}
+	// the sources hold the tank's heat above the indoor temperature
+	stored := tank.sources.solarEnergy + tank.sources.auxiliaryEnergy + tank.sources.otherEnergy
+	if expected := tank.getHeatCapacity() * (tank.temperature - 20); math.Abs(stored-expected)/expected > 1e-2 {
+		t.Errorf("expected %v J tracked, got %v J", expected, stored)
+	}
+}
@@ -0,0 +1,188 @@
+// the steady-state solver finds the temperatures at which every system's net heat is zero.
+// It uses Newton iteration on the same component heat functions the transient simulation uses,
+// so the solution is consistent with the final state of a long enough transient run.
+package main
+
+import (
+	"errors"
+	"math"
+)
+
+const (
+	steadyStateMaxIterations = 50
+	steadyStateTolerance     = 1e-6 // W; largest absolute net heat allowed at the solution
+	steadyStatePerturbation  = 1e-3 // K; step used for the finite difference jacobian
+)
+
+// ISteadyStateSystem is a fluid system whose temperature the solver can set directly
+type ISteadyStateSystem interface {
+	IFluidSystem
+	setTemp(temp float64)
+	getNetHeat() float64
+}
+
+type steadyStateResult struct {
+	converged    bool
+	iterations   int
+	residual     float64                       // W; largest absolute net heat of any system
+	temperatures map[string]float64            // system name -> temperature
+	heatFlows    map[string]map[string]float64 // system name -> component name -> heat rate
+}
+
+// solveSteadyState runs Newton iteration on the systems' temperatures.
+// The systems are left at their original temperatures and their recorded data is not modified.
+func solveSteadyState(systems []ISteadyStateSystem) steadyStateResult {
+	initialTemps := make([]float64, len(systems))
+	dataLengths := make([]map[string]int, len(systems))
+	for i, sys := range systems {
+		initialTemps[i] = sys.getTemp()
+		dataLengths[i] = map[string]int{}
+		for name, series := range sys.getData() {
+			dataLengths[i][name] = len(*series)
+		}
+	}
+
+	result := steadyStateResult{
+		temperatures: map[string]float64{},
+		heatFlows:    map[string]map[string]float64{},
+	}
+
+	temps := append([]float64{}, initialTemps...)
+	residuals := evaluateSteadyStateResiduals(systems, temps)
+	result.residual = maxAbs(residuals)
+	for result.iterations < steadyStateMaxIterations && result.residual > steadyStateTolerance {
+		result.iterations++
+
+		// build the jacobian by perturbing one temperature at a time
+		jacobian := make([][]float64, len(systems))
+		for i := range jacobian {
+			jacobian[i] = make([]float64, len(systems))
+		}
+		for j := range systems {
+			perturbed := append([]float64{}, temps...)
+			perturbed[j] += steadyStatePerturbation
+			perturbedResiduals := evaluateSteadyStateResiduals(systems, perturbed)
+			for i := range systems {
+				jacobian[i][j] = (perturbedResiduals[i] - residuals[i]) / steadyStatePerturbation
+			}
+		}
+
+		// solve J·Δ = -r
+		negResiduals := make([]float64, len(residuals))
+		for i, r := range residuals {
+			negResiduals[i] = -r
+		}
+		delta, err := solveLinearSystem(jacobian, negResiduals)
+		if err != nil {
+			break
+		}
+
+		// backtrack until the step reduces the residual, which keeps strongly nonlinear components stable
+		stepSize := 1.0
+		for {
+			candidate := make([]float64, len(temps))
+			for i := range temps {
+				candidate[i] = temps[i] + stepSize*delta[i]
+			}
+			candidateResiduals := evaluateSteadyStateResiduals(systems, candidate)
+			if maxAbs(candidateResiduals) < result.residual || stepSize < 1e-4 {
+				temps = candidate
+				residuals = candidateResiduals
+				break
+			}
+			stepSize /= 2
+		}
+		result.residual = maxAbs(residuals)
+	}
+	result.converged = result.residual <= steadyStateTolerance
+
+	// residuals were last evaluated at the solution, so the newest data points are the solution's heat flows
+	for i, sys := range systems {
+		result.temperatures[sys.getName()] = temps[i]
+		flows := map[string]float64{}
+		data := sys.getData()
+		for name, series := range data {
+			if len(*series) > dataLengths[i][name] {
+				flows[name] = (*series)[len(*series)-1].Value.(float64)
+			}
+		}
+		result.heatFlows[sys.getName()] = flows
+
+		// discard the data points produced by the solver
+		for name, series := range data {
+			if length, ok := dataLengths[i][name]; ok {
+				*series = (*series)[:length]
+			} else {
+				delete(data, name)
+			}
+		}
+		sys.setTemp(initialTemps[i])
+	}
+
+	return result
+}
+
+// evaluateSteadyStateResiduals steps every system at the given temperatures and returns their net heat
+func evaluateSteadyStateResiduals(systems []ISteadyStateSystem, temps []float64) []float64 {
+	for i, sys := range systems {
+		sys.setTemp(temps[i])
+	}
+	for _, sys := range systems {
+		sys.reset()
+	}
+	for _, sys := range systems {
+		sys.step()
+	}
+	residuals := make([]float64, len(systems))
+	for i, sys := range systems {
+		residuals[i] = sys.getNetHeat()
+	}
+	return residuals
+}
+
+// solveLinearSystem solves A·x = b with gaussian elimination and partial pivoting.
+// a and b are not modified.
+func solveLinearSystem(a [][]float64, b []float64) ([]float64, error) {
+	n := len(b)
+	m := make([][]float64, n)
+	for i := range a {
+		m[i] = append(append([]float64{}, a[i]...), b[i])
+	}
+
+	for col := 0; col < n; col++ {
+		pivot := col
+		for row := col + 1; row < n; row++ {
+			if math.Abs(m[row][col]) > math.Abs(m[pivot][col]) {
+				pivot = row
+			}
+		}
+		if math.Abs(m[pivot][col]) < float64EqualityThreshold {
+			return nil, errors.New("singular matrix")
+		}
+		m[col], m[pivot] = m[pivot], m[col]
+		for row := col + 1; row < n; row++ {
+			factor := m[row][col] / m[col][col]
+			for k := col; k <= n; k++ {
+				m[row][k] -= factor * m[col][k]
+			}
+		}
+	}
+
+	x := make([]float64, n)
+	for row := n - 1; row >= 0; row-- {
+		sum := m[row][n]
+		for k := row + 1; k < n; k++ {
+			sum -= m[row][k] * x[k]
+		}
+		x[row] = sum / m[row][row]
+	}
+	return x, nil
+}
+
+func maxAbs(values []float64) float64 {
+	result := 0.0
+	for _, v := range values {
+		result = math.Max(result, math.Abs(v))
+	}
+	return result
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSolveSteadyState_SingleSystem(t *testing.T) {
+	fs := &fluidSystem{
+		name:               "System",
+		exposedSurfaceArea: 2.0,
+		ambientTemp:        15.0,
+		ambientHTC:         10.0,
+		fluidMass:          10.0,
+		temperature:        30.0,
+		heatInComponents: []IComponent{
+			mockHeatComponent{
+				mockComponent: mockComponent{name: "Heat In"},
+				heat:          1000.0,
+			},
+		},
+	}
+	fs.addEnvironmentalConvectionHeatLossComponent()
+
+	result := solveSteadyState([]ISteadyStateSystem{fs})
+	if !result.converged {
+		t.Fatalf("expected solver to converge, residual %v", result.residual)
+	}
+	expectedTemp := 15.0 + 1000.0/(10.0*2.0) // T = Tₐ + q/hA
+	if math.Abs(result.temperatures["System"]-expectedTemp) > 1e-6 {
+		t.Errorf("expected %v, got %v", expectedTemp, result.temperatures["System"])
+	}
+	if heat := result.heatFlows["System"]["Ambient Convection Heat Loss"]; math.Abs(heat-1000.0) > 1e-6 {
+		t.Errorf("expected ambient loss of 1000, got %v", heat)
+	}
+	if fs.temperature != 30.0 {
+		t.Errorf("expected system temperature to be restored to 30, got %v", fs.temperature)
+	}
+	if len(fs.getData()) != 0 {
+		t.Errorf("expected solver data points to be discarded, got %v series", len(fs.getData()))
+	}
+}
+
+func TestSolveSteadyState_CoupledSystems(t *testing.T) {
+	sp := &solarPanel{
+		fluidSystem: fluidSystem{
+			name:               "SolarPanel",
+			exposedSurfaceArea: 2.0,
+			ambientTemp:        15.0,
+			ambientHTC:         15.0,
+			fluidMass:          10.0,
+			temperature:        30.0,
+		},
+		panelArea:       2.0,
+		panelEfficiency: 0.6,
+		solarIrradiance: 1000.0,
+	}
+	st := &storageTank{
+		fluidSystem: fluidSystem{
+			name:               "StorageTank",
+			exposedSurfaceArea: 3.0,
+			ambientTemp:        22.0,
+			ambientHTC:         5.0,
+			fluidMass:          250.0,
+			temperature:        20.0,
+		},
+	}
+	sp.initialize([]IFluidSystem{&st.fluidSystem}, mockVariableIntegrator(0.2))
+	st.initialize([]IFluidSystem{&sp.fluidSystem}, mockVariableIntegrator(0.2))
+
+	result := solveSteadyState([]ISteadyStateSystem{sp, st})
+	if !result.converged {
+		t.Fatalf("expected solver to converge, residual %v", result.residual)
+	}
+
+	// at steady state all absorbed heat is lost to the two ambient environments
+	absorbed := 0.6 * 1000.0 * 2.0
+	lost := 15.0*2.0*(result.temperatures["SolarPanel"]-15.0) + 5.0*3.0*(result.temperatures["StorageTank"]-22.0)
+	if math.Abs(absorbed-lost) > 1e-3 {
+		t.Errorf("expected absorbed heat %v to equal lost heat %v", absorbed, lost)
+	}
+}
+
+func TestSolveLinearSystem(t *testing.T) {
+	a := [][]float64{
+		{0.0, 2.0, 1.0},
+		{1.0, 1.0, 0.0},
+		{3.0, 0.0, 1.0},
+	}
+	b := []float64{7.0, 3.0, 6.0}
+	x, err := solveLinearSystem(a, b)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := []float64{1.0, 2.0, 3.0}
+	for i := range expected {
+		if math.Abs(x[i]-expected[i]) > 1e-9 {
+			t.Errorf("expected %v at index %v, got %v", expected[i], i, x[i])
+		}
+	}
+
+	if _, err := solveLinearSystem([][]float64{{1.0, 2.0}, {2.0, 4.0}}, []float64{1.0, 2.0}); err == nil {
+		t.Error("expected error for singular matrix")
+	}
+}
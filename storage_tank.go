@@ -1,10 +1,84 @@
 package main
 
+import "math"
+
+const (
+	singleTankLayout = "single"
+	// the collector charges a preheat tank, and draws flow through the preheat tank into the final tank
+	cascadeTankLayout = "cascade"
+	// draws flow through both tanks in series, and a diverter valve sends the collector's flow to either tank
+	diverterTankLayout = "diverter"
+
+	verticalTankOrientation   = "vertical"
+	horizontalTankOrientation = "horizontal"
+)
+
+// tankSizes are typical vertical tanks by their nominal volume in L
+var tankSizes = map[string]struct{ height, diameter float64 }{
+	"150": {height: 0.94, diameter: 0.45},
+	"300": {height: 1.26, diameter: 0.55},
+	"500": {height: 1.51, diameter: 0.65},
+}
+
+// tankGeometry is a cylindrical tank and its insulation. A vertical tank's top and bottom are its round ends,
+// and its side is the shell. A horizontal tank's top and bottom are the upper and lower halves of its shell,
+// and its sides are its round ends.
+type tankGeometry struct {
+	height                 float64 // m; along the axis, which is its length when horizontal
+	diameter               float64 // m
+	orientation            string
+	insulationThickness    float64 // m
+	insulationConductivity float64 // W/(m*K)
+	topLoss                bool
+	sideLoss               bool
+	bottomLoss             bool
+}
+
+func (g tankGeometry) getRadius() float64 {
+	return g.diameter / 2
+}
+
+// getVolume is V = πr²h
+func (g tankGeometry) getVolume() float64 {
+	return math.Pi * math.Pow(g.getRadius(), 2) * g.height
+}
+
+// getLossArea is the area of the surfaces that lose heat
+func (g tankGeometry) getLossArea() float64 {
+	radius := g.getRadius()
+	// A = πr² for each end, and 2πrh for the shell
+	ends, shell := math.Pi*math.Pow(radius, 2), 2*math.Pi*radius*g.height
+	top, side, bottom := ends, shell, ends
+	if g.orientation == horizontalTankOrientation {
+		top, side, bottom = shell/2, 2*ends, shell/2
+	}
+	area := 0.0
+	if g.topLoss {
+		area += top
+	}
+	if g.sideLoss {
+		area += side
+	}
+	if g.bottomLoss {
+		area += bottom
+	}
+	return area
+}
+
+// getUValue is the surface's heat transfer coefficient in series with the insulation: U = 1/(1/h + t/k)
+func (g tankGeometry) getUValue(htc float64) float64 {
+	if g.insulationThickness <= 0 {
+		return htc
+	}
+	return 1 / (1/htc + g.insulationThickness/g.insulationConductivity)
+}
+
 type storageTank struct {
 	fluidSystem
+	sources *tankHeatSources // optional; tracks where the tank's heat came from
 }
 
-func (st *storageTank) initialize(fluidOutputs []IFluidSystem, flowRate float64) {
+func (st *storageTank) initialize(fluidOutputs []IFluidSystem, flowRate variableIntegrator) {
 	// include all the power components involved in this system
 	st.heatOutComponents = []IComponent{}
 	st.addEnvironmentalConvectionHeatLossComponent()
@@ -12,3 +86,28 @@ func (st *storageTank) initialize(fluidOutputs []IFluidSystem, flowRate float64)
 		st.addOutputHeatFluidComponent(output, flowRate)
 	}
 }
+
+// commit credits the step's heat to its sources before it is stored
+func (st *storageTank) commit(timeStep float64) {
+	if st.sources != nil {
+		st.sources.update(timeStep)
+	}
+	st.fluidSystem.commit(timeStep)
+}
+
+func (st *storageTank) getState() map[string]float64 {
+	state := st.fluidSystem.getState()
+	if st.sources != nil {
+		for key, value := range st.sources.getState() {
+			state[key] = value
+		}
+	}
+	return state
+}
+
+func (st *storageTank) setState(state map[string]float64) error {
+	if st.sources != nil {
+		st.sources.setState(state)
+	}
+	return st.fluidSystem.setState(state)
+}
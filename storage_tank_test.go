@@ -0,0 +1,57 @@
+package main
+
+import (
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestTankGeometry(t *testing.T) {
+	vertical := tankGeometry{height: 2, diameter: 1, orientation: verticalTankOrientation, topLoss: true, sideLoss: true}
+	if volume := vertical.getVolume(); math.Abs(volume-math.Pi/2) > float64EqualityThreshold {
+		t.Errorf("expected a volume of %v, got %v", math.Pi/2, volume)
+	}
+
+	// the ends are π/4 m² each and the shell is 2π m²
+	tests := []struct {
+		name     string
+		geometry tankGeometry
+		expected float64
+	}{
+		{name: "Vertical on a base", geometry: vertical, expected: math.Pi/4 + 2*math.Pi},
+		{name: "Vertical top only", geometry: tankGeometry{height: 2, diameter: 1, orientation: verticalTankOrientation, topLoss: true}, expected: math.Pi / 4},
+		{name: "Horizontal on a base", geometry: tankGeometry{height: 2, diameter: 1, orientation: horizontalTankOrientation, topLoss: true, sideLoss: true}, expected: math.Pi + math.Pi/2},
+		{name: "Horizontal all round", geometry: tankGeometry{height: 2, diameter: 1, orientation: horizontalTankOrientation, topLoss: true, sideLoss: true, bottomLoss: true}, expected: 2*math.Pi + math.Pi/2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if area := tt.geometry.getLossArea(); math.Abs(area-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected a loss area of %v, got %v", tt.expected, area)
+			}
+		})
+	}
+
+	// without insulation the surface coefficient is the U-value
+	if u := vertical.getUValue(5); u != 5 {
+		t.Errorf("expected an uninsulated U-value of 5, got %v", u)
+	}
+	// 50 mm of foam at 0.04 W/(m·K) is 1.25 m²·K/W, in series with the surface's 0.2 m²·K/W
+	vertical.insulationThickness, vertical.insulationConductivity = 0.05, 0.04
+	if u := vertical.getUValue(5); math.Abs(u-1/1.45) > float64EqualityThreshold {
+		t.Errorf("expected an insulated U-value of %v, got %v", 1/1.45, u)
+	}
+}
+
+func TestTankSizes(t *testing.T) {
+	// the presets are within a few percent of their nominal volume
+	for size, dimensions := range tankSizes {
+		geometry := tankGeometry{height: dimensions.height, diameter: dimensions.diameter}
+		nominal, err := strconv.ParseFloat(size, 64)
+		if err != nil {
+			t.Fatalf("expected a preset in L, got %q", size)
+		}
+		if volume := geometry.getVolume() * 1000; math.Abs(volume-nominal)/nominal > 0.03 {
+			t.Errorf("expected the %s L preset to hold about %v L, got %v", size, nominal, volume)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// surface condition: snow and soiling on the panel's cover, which block part of the radiation reaching the absorber.
+// Snow builds up from precipitation below freezing, melts with heat from the panel, and slides off a warm tilted panel.
+// Dust builds up between rain events, and a heavy enough rain washes it off.
+package main
+
+import "math"
+
+const (
+	snowfallTemp     = 1.0    // Celsius; precipitation falls as snow at or below this air temperature
+	fullSnowCover    = 2.0    // kg/m^2; snow water equivalent that covers the panel completely
+	snowSlideRate    = 0.197  // covered fraction per hour on a vertical panel, scaled by the sine of the tilt (Marion et al.)
+	snowMeltHTC      = 10.0   // W/(m^2*K); panel to snow
+	latentHeatFusion = 334000 // J/kg
+)
+
+type surfaceCondition struct {
+	name          string
+	tilt          float64            // degrees from horizontal
+	surfaceArea   float64            // m^2
+	precipitation variableIntegrator // mm/h of water, which is kg/(m^2*h)
+	airTemp       variableIntegrator
+	panelTemp     variableIntegrator // set by the panel
+	irradiance    variableIntegrator // W/m^2; before the losses, set by the panel
+	soilingRate   float64            // soiling loss gained per day without rain, as a fraction
+	maxSoiling    float64            // fraction
+	cleaningRain  float64            // mm/h; rain at least this heavy washes the panel
+	// the condition of the surface
+	snowMass   float64 // kg/m^2; snow water equivalent
+	snowCover  float64 // fraction of the panel covered
+	soiling    float64 // fraction of the radiation blocked by dirt
+	meltHeat   float64 // W; taken from the panel during the current step
+	lostEnergy float64 // J; radiation blocked by snow and soiling, total over the run
+	dataRecorder
+}
+
+func (c *surfaceCondition) getName() string {
+	return c.name
+}
+
+func (c *surfaceCondition) update(time float64, timeStep float64) {
+	precipitation := math.Max(0, c.precipitation())
+	hours := timeStep / (60 * 60)
+	panelTemp := c.panelTemp()
+
+	if c.airTemp() <= snowfallTemp {
+		c.snowMass += precipitation * hours
+		c.snowCover = math.Min(1, c.snowCover+precipitation*hours/fullSnowCover)
+	} else if precipitation > 0 && precipitation >= c.cleaningRain {
+		c.soiling = 0.0
+	}
+	if precipitation == 0 {
+		c.soiling = math.Min(c.maxSoiling, c.soiling+c.soilingRate*timeStep/(24*60*60))
+	}
+
+	c.meltHeat = 0.0
+	if c.snowMass > 0 && c.snowCover > 0 && panelTemp > 0 {
+		// a thawed layer under the snow lets it slide off, taking its share of the snow with it
+		cover := math.Max(0, c.snowCover-snowSlideRate*math.Sin(c.tilt*degreesToRadians)*hours)
+		c.snowMass *= cover / c.snowCover
+		c.snowCover = cover
+
+		// q = hA(T - 0 °C) over the covered area, and no more than melts the remaining snow
+		c.meltHeat = math.Min(snowMeltHTC*c.surfaceArea*c.snowCover*panelTemp, c.snowMass*c.surfaceArea*latentHeatFusion/timeStep)
+		c.snowMass -= c.meltHeat * timeStep / (c.surfaceArea * latentHeatFusion)
+	}
+	if c.snowMass <= 0 {
+		c.snowMass, c.snowCover = 0.0, 0.0
+	}
+
+	radiation := c.irradiance() * c.surfaceArea
+	snowLoss, soilingLoss := radiation*c.snowCover, radiation*(1-c.snowCover)*c.soiling
+	c.lostEnergy += (snowLoss + soilingLoss) * timeStep
+	c.addDataPoint("Snow Loss", snowLoss)
+	c.addDataPoint("Soiling Loss", soilingLoss)
+	c.addDataPoint("Snow Cover", c.snowCover)
+}
+
+// getTransmittance is the fraction of the radiation that reaches the absorber
+func (c *surfaceCondition) getTransmittance() float64 {
+	return (1 - c.snowCover) * (1 - c.soiling)
+}
+
+func (c *surfaceCondition) getMeltHeat() float64 {
+	return c.meltHeat
+}
+
+func (c *surfaceCondition) getState() map[string]float64 {
+	return map[string]float64{
+		"snowMass":   c.snowMass,
+		"snowCover":  c.snowCover,
+		"soiling":    c.soiling,
+		"lostEnergy": c.lostEnergy,
+	}
+}
+
+func (c *surfaceCondition) setState(state map[string]float64) error {
+	c.snowMass = state["snowMass"]
+	c.snowCover = state["snowCover"]
+	c.soiling = state["soiling"]
+	c.lostEnergy = state["lostEnergy"]
+	return nil
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestSurfaceConditionSnow(t *testing.T) {
+	precipitation := 2.0
+	airTemp := -5.0
+	panelTemp := -2.0
+	c := surfaceCondition{
+		name:          "SurfaceCondition",
+		tilt:          30,
+		surfaceArea:   2,
+		precipitation: func() float64 { return precipitation },
+		airTemp:       func() float64 { return airTemp },
+		panelTemp:     func() float64 { return panelTemp },
+		irradiance:    mockVariableIntegrator(500),
+		cleaningRain:  1,
+	}
+
+	// an hour of snow covers the panel
+	c.update(0, 60*60)
+	if c.snowMass != 2 || c.snowCover != 1 || c.getTransmittance() != 0 {
+		t.Errorf("expected a covered panel, got %v kg/m^2 covering %v", c.snowMass, c.snowCover)
+	}
+	if loss := (*c.data["Snow Loss"])[0].Value.(float64); loss != 1000 {
+		t.Errorf("expected 1000 W of radiation lost to snow, got %v W", loss)
+	}
+	if c.lostEnergy != 1000*60*60 {
+		t.Errorf("expected %v J lost over the hour, got %v J", 1000*60*60, c.lostEnergy)
+	}
+
+	// a warm panel melts the snow and lets part of it slide off
+	precipitation = 0
+	panelTemp = 5
+	c.update(60*60, 60)
+	cover := 1 - snowSlideRate*0.5/60
+	if math.Abs(c.snowCover-cover) > 1e-9 {
+		t.Errorf("expected %v of the panel covered, got %v", cover, c.snowCover)
+	}
+	meltHeat := snowMeltHTC * 2 * cover * 5
+	if math.Abs(c.getMeltHeat()-meltHeat) > 1e-9 {
+		t.Errorf("expected %v W to melt snow, got %v W", meltHeat, c.getMeltHeat())
+	}
+	if snowMass := 2*cover - meltHeat*60/(2*latentHeatFusion); math.Abs(c.snowMass-snowMass) > 1e-9 {
+		t.Errorf("expected %v kg/m^2 of snow, got %v", snowMass, c.snowMass)
+	}
+
+	// the last of the snow melts without taking more heat than it needs
+	c.tilt = 0
+	c.snowMass = 1e-6
+	c.update(60*60+60, 60)
+	if c.snowMass != 0 || c.snowCover != 0 {
+		t.Errorf("expected the snow to be gone, got %v kg/m^2 covering %v", c.snowMass, c.snowCover)
+	}
+	if math.Abs(c.getMeltHeat()-1e-6*2*latentHeatFusion/60) > 1e-9 {
+		t.Errorf("unexpected melt heat %v W", c.getMeltHeat())
+	}
+}
+
+func TestSurfaceConditionSoiling(t *testing.T) {
+	precipitation := 0.0
+	c := surfaceCondition{
+		name:          "SurfaceCondition",
+		surfaceArea:   2,
+		precipitation: func() float64 { return precipitation },
+		airTemp:       mockVariableIntegrator(20),
+		panelTemp:     mockVariableIntegrator(40),
+		irradiance:    mockVariableIntegrator(500),
+		soilingRate:   0.01,
+		maxSoiling:    0.05,
+		cleaningRain:  1,
+	}
+	for day := 0; day < 3; day++ {
+		c.update(float64(day)*24*60*60, 24*60*60)
+	}
+	if math.Abs(c.soiling-0.03) > float64EqualityThreshold {
+		t.Errorf("expected 3%% soiling, got %v", c.soiling)
+	}
+	for day := 3; day < 10; day++ {
+		c.update(float64(day)*24*60*60, 24*60*60)
+	}
+	if c.soiling != 0.05 {
+		t.Errorf("expected soiling to stop at 5%%, got %v", c.soiling)
+	}
+
+	// light rain doesn't clean the panel, but heavy rain does
+	precipitation = 0.5
+	c.update(10*24*60*60, 60)
+	if c.soiling != 0.05 {
+		t.Errorf("expected light rain to leave the soiling, got %v", c.soiling)
+	}
+	precipitation = 2
+	c.update(10*24*60*60+60, 60)
+	if c.soiling != 0 || c.getTransmittance() != 1 {
+		t.Errorf("expected heavy rain to clean the panel, got %v", c.soiling)
+	}
+}
@@ -1,6 +1,8 @@
 package main
 
 import (
+	"errors"
+
 	"github.com/go-echarts/go-echarts/v2/opts"
 )
 
@@ -22,15 +24,23 @@ type fluidSystem struct {
 	name               string
 	exposedSurfaceArea float64 // m^2; surface area exposed to the ambient environment
 	ambientTemp        float64
+	ambient            IFluidSystem // optional; replaces the constant ambientTemp, and receives the heat lost to ambient
 	ambientHTC         float64
+	convection         convectionCorrelation // optional; replaces the constant ambientHTC
 	fluidMass          float64
+	fluid              *fluid  // optional; a constant specificHeatWater is used without a fluid
+	dryHeatCapacity    float64 // J/K; thermal mass of the system's solid parts, e.g. the absorber plate and glazing
 	temperature        float64 // internal fluid temp
+	frozenFraction     float64 // fraction of the fluid's water that is frozen
+	vaporFraction      float64 // fraction of the fluid's water that has boiled
 	heatInComponents   []IComponent
 	heatOutComponents  []IComponent
 	// the step- prefix values need to be reset separately from the step function
-	stepHeatIn  []float64
-	stepHeatOut []float64
-	powerData   map[string]*[]opts.LineData
+	stepHeatIn   []float64
+	stepHeatOut  []float64
+	stepRecorded map[string]bool // data series that already have a point for this step
+	powerData    map[string]*[]opts.LineData
+	warnings     []string
 }
 
 func (fs fluidSystem) getName() string {
@@ -45,27 +55,96 @@ func (fs fluidSystem) getData() map[string]*[]opts.LineData {
 	return fs.powerData
 }
 
+func (fs fluidSystem) getState() map[string]float64 {
+	return map[string]float64{
+		"temperature":    fs.temperature,
+		"frozenFraction": fs.frozenFraction,
+		"vaporFraction":  fs.vaporFraction,
+	}
+}
+
+func (fs *fluidSystem) setState(state map[string]float64) error {
+	temperature, ok := state["temperature"]
+	if !ok {
+		return errors.New("missing temperature")
+	}
+	fs.temperature = temperature
+	fs.frozenFraction = state["frozenFraction"]
+	fs.vaporFraction = state["vaporFraction"]
+	return nil
+}
+
+func (fs *fluidSystem) setData(data map[string]*[]opts.LineData) {
+	fs.powerData = data
+}
+
+func (fs *fluidSystem) setTemp(temp float64) {
+	fs.temperature = temp
+}
+
+// getAmbientTemp returns the temperature of the system's surroundings
+func (fs fluidSystem) getAmbientTemp() float64 {
+	if fs.ambient != nil {
+		return fs.ambient.getTemp()
+	}
+	return fs.ambientTemp
+}
+
 func (fs *fluidSystem) addEnvironmentalConvectionHeatLossComponent() {
-	fs.heatOutComponents = append(fs.heatOutComponents, &ambientConvectionHeatComponent{
+	convection := &ambientConvectionHeatComponent{
 		component: component{
 			name: "Ambient Convection Heat Loss",
 		},
-		ambientHTC:  fs.ambientHTC,
+		ambientHTC: func() float64 {
+			if fs.convection != nil {
+				return fs.convection((*fs).temperature, (*fs).getAmbientTemp())
+			}
+			return (*fs).ambientHTC
+		},
 		surfaceArea: fs.exposedSurfaceArea,
 		currentTemp: func() float64 { return (*fs).temperature },
-		ambientTemp: func() float64 { return (*fs).ambientTemp },
+		ambientTemp: func() float64 { return (*fs).getAmbientTemp() },
+	}
+	if fs.ambient == nil {
+		fs.heatOutComponents = append(fs.heatOutComponents, convection)
+		return
+	}
+	// the heat lost to an ambient system warms it
+	fs.heatOutComponents = append(fs.heatOutComponents,
+		transferHeatComponentWrapper{
+			component: component{
+				name: "Ambient Convection Heat Loss",
+			},
+			wrappedComponent: convection,
+			output:           fs.ambient,
+		})
+}
+
+// addRadiationHeatLossComponent adds long-wave radiation loss to the sky, with the rest of the view
+// exchanging with surroundings at the ambient temperature
+func (fs *fluidSystem) addRadiationHeatLossComponent(emissivity float64, skyViewFactor float64, skyTemp variableIntegrator) {
+	fs.heatOutComponents = append(fs.heatOutComponents, &radiationHeatComponent{
+		component: component{
+			name: "Radiation Heat Loss",
+		},
+		emissivity:       emissivity,
+		surfaceArea:      fs.exposedSurfaceArea,
+		skyViewFactor:    skyViewFactor,
+		currentTemp:      func() float64 { return (*fs).temperature },
+		skyTemp:          skyTemp,
+		surroundingsTemp: func() float64 { return (*fs).getAmbientTemp() },
 	})
 }
 
-func (fs *fluidSystem) addOutputHeatFluidComponent(output IFluidSystem, flowRate float64) {
+func (fs *fluidSystem) addOutputHeatFluidComponent(output IFluidSystem, flowRate variableIntegrator) {
 	fs.heatOutComponents = append(fs.heatOutComponents,
 		transferHeatComponentWrapper{
 			component: component{
 				name: "Heat Output",
 			},
 			wrappedComponent: heatCapacityFluidComponent{
-				flowMass:     func() float64 { return flowRate },
-				specificHeat: specificHeatWater,
+				flowMass:     flowRate,
+				specificHeat: func() float64 { return (*fs).getSpecificHeat() },
 				currentTemp:  func() float64 { return (*fs).temperature },
 				outputTemp:   func() float64 { return output.getTemp() },
 			},
@@ -73,9 +152,24 @@ func (fs *fluidSystem) addOutputHeatFluidComponent(output IFluidSystem, flowRate
 		})
 }
 
+// addInflowComponent adds fluid entering the system at upstreamTemp, which gains ṁc(Tᵤₚ - T).
+// The fluid leaves at the system's temperature, so the heat it carries away is already accounted for.
+func (fs *fluidSystem) addInflowComponent(name string, flowMass variableIntegrator, upstreamTemp variableIntegrator) {
+	fs.heatInComponents = append(fs.heatInComponents, heatCapacityFluidComponent{
+		component: component{
+			name: name,
+		},
+		flowMass:     flowMass,
+		specificHeat: func() float64 { return (*fs).getSpecificHeat() },
+		currentTemp:  upstreamTemp,
+		outputTemp:   func() float64 { return (*fs).temperature },
+	})
+}
+
 func (fs *fluidSystem) reset() {
 	fs.stepHeatIn = []float64{}
 	fs.stepHeatOut = []float64{}
+	fs.stepRecorded = map[string]bool{}
 }
 
 func (fs *fluidSystem) step() {
@@ -83,7 +177,7 @@ func (fs *fluidSystem) step() {
 		if heatComp, ok := comp.(IHeatComponent); ok {
 			q := heatComp.getHeat()
 			fs.stepHeatIn = append(fs.stepHeatIn, q)
-			fs.addDataPoint(heatComp.getName(), q)
+			fs.addStepDataPoint(heatComp.getName(), q)
 		}
 	}
 
@@ -91,7 +185,7 @@ func (fs *fluidSystem) step() {
 		if heatComp, ok := comp.(IHeatComponent); ok {
 			q := heatComp.getHeat()
 			fs.stepHeatOut = append(fs.stepHeatOut, q)
-			fs.addDataPoint(heatComp.getName(), q)
+			fs.addStepDataPoint(heatComp.getName(), q)
 		}
 		if fluidComp, ok := comp.(transferHeatComponentWrapper); ok {
 			q := fluidComp.getHeat()
@@ -100,8 +194,21 @@ func (fs *fluidSystem) step() {
 	}
 }
 
-func (fs *fluidSystem) commit(timeStep float64) {
-	// compute change in internal temperature
+// getSpecificHeat returns the specific heat of the system's fluid at its current temperature
+func (fs fluidSystem) getSpecificHeat() float64 {
+	if fs.fluid == nil {
+		return specificHeatWater
+	}
+	return fs.fluid.getSpecificHeat(fs.temperature)
+}
+
+// getHeatCapacity returns the heat capacity of the system's fluid and dry mass together
+func (fs fluidSystem) getHeatCapacity() float64 {
+	return fs.fluidMass*fs.getSpecificHeat() + fs.dryHeatCapacity
+}
+
+// getNetHeat returns the heat stored by the system during the current step
+func (fs fluidSystem) getNetHeat() float64 {
 	// qᵢ - q₀ = qₛ
 	heatStored := 0.0
 	for _, q := range fs.stepHeatIn {
@@ -110,16 +217,51 @@ func (fs *fluidSystem) commit(timeStep float64) {
 	for _, q := range fs.stepHeatOut {
 		heatStored -= q
 	}
+	return heatStored
+}
+
+func (fs *fluidSystem) commit(timeStep float64) {
+	// compute change in internal temperature
+	heatStored := fs.getNetHeat()
+	if fs.fluid != nil {
+		// fluids with known phase change temperatures account for latent heat
+		fs.storeHeat(heatStored * timeStep)
+		return
+	}
 
 	// solve the heat capacity function for T₀
-	// T₀ = q/ṁC + Tᵢ
+	// T₀ = q/(ṁC + C_dry) + Tᵢ
 	// note ṁ is in kg/s, so we need to factor in time passed
-	fs.temperature = heatStored/((fs.fluidMass/timeStep)*specificHeatWater) + fs.temperature
+	fs.temperature = heatStored/((fs.fluidMass/timeStep)*fs.getSpecificHeat()+fs.dryHeatCapacity/timeStep) + fs.temperature
 }
 
 func (fs *fluidSystem) inputHeatCallback(heat float64) {
 	fs.stepHeatIn = append(fs.stepHeatIn, heat)
-	fs.addDataPoint("Heat Input", heat)
+	fs.addStepDataPoint("Heat Input", heat)
+}
+
+// addStepDataPoint records one data point per step for each series:
+// several components with the same name, e.g. outputs to several systems, record their total
+func (fs *fluidSystem) addStepDataPoint(name string, value float64) {
+	if fs.stepRecorded == nil {
+		fs.stepRecorded = map[string]bool{}
+	}
+	if fs.stepRecorded[name] {
+		series := *fs.powerData[name]
+		series[len(series)-1].Value = series[len(series)-1].Value.(float64) + value
+		return
+	}
+	fs.stepRecorded[name] = true
+	fs.addDataPoint(name, value)
+}
+
+// getStepHeat is the heat recorded in a series during the current step, or 0 if none was recorded
+func (fs *fluidSystem) getStepHeat(name string) float64 {
+	if !fs.stepRecorded[name] {
+		return 0.0
+	}
+	series := *fs.powerData[name]
+	return series[len(series)-1].Value.(float64)
 }
 
 func (fs *fluidSystem) addDataPoint(name string, value float64) {
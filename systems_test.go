@@ -1,6 +1,7 @@
 package main
 
 import (
+	"math"
 	"testing"
 )
 
@@ -131,6 +132,27 @@ func TestFluidSystem_Step(t *testing.T) {
 	}
 }
 
+func TestFluidSystem_StepSameNamedComponents(t *testing.T) {
+	// outputs to two tanks share a name, and are recorded as one data point per step
+	fs := fluidSystem{
+		heatOutComponents: []IComponent{
+			mockHeatComponent{mockComponent: mockComponent{name: "Heat Output"}, heat: 300.0},
+			mockHeatComponent{mockComponent: mockComponent{name: "Heat Output"}, heat: 200.0},
+		},
+	}
+	for i := 0; i < 2; i++ {
+		fs.reset()
+		fs.step()
+	}
+	series := *fs.getData()["Heat Output"]
+	if len(series) != 2 {
+		t.Fatalf("expected one data point per step, got %v", len(series))
+	}
+	if series[1].Value != 500.0 {
+		t.Errorf("expected the outputs' total of 500, got %v", series[1].Value)
+	}
+}
+
 func TestFluidSystem_Commit(t *testing.T) {
 	fs := fluidSystem{
 		fluidMass:   2.0,
@@ -144,3 +166,138 @@ func TestFluidSystem_Commit(t *testing.T) {
 		t.Errorf("expected %v, got %v", expectedTemp, fs.temperature)
 	}
 }
+
+func TestFluidSystem_CommitPhaseChange(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	glycol, _ := getFluid("propylene-glycol-40")
+	tests := []struct {
+		name                   string
+		fluid                  *fluid
+		temperature            float64
+		frozenFraction         float64
+		heat                   float64 // W, over a 1s step
+		expectedTemp           float64
+		expectedFrozenFraction float64
+		expectedVaporFraction  float64
+		expectedWarnings       int
+	}{
+		{
+			name:         "Liquid",
+			fluid:        water,
+			temperature:  20.0,
+			heat:         1000.0,
+			expectedTemp: 20.0 + 1000.0/(2.0*4182),
+		},
+		{
+			name:                   "Starts Freezing",
+			fluid:                  water,
+			temperature:            1.0,
+			heat:                   -2.0*4215.25*1.0 - 0.5*2.0*334000,
+			expectedTemp:           0.0,
+			expectedFrozenFraction: 0.5,
+			expectedWarnings:       1,
+		},
+		{
+			name:                   "Continues Melting",
+			fluid:                  water,
+			temperature:            0.0,
+			frozenFraction:         0.5,
+			heat:                   0.25 * 2.0 * 334000,
+			expectedTemp:           0.0,
+			expectedFrozenFraction: 0.25,
+		},
+		{
+			name:         "Glycol Stays Liquid Below 0",
+			fluid:        glycol,
+			temperature:  -5.0,
+			heat:         -100.0,
+			expectedTemp: -5.0 - 100.0/(2.0*3680),
+		},
+		{
+			name:                  "Starts Boiling",
+			fluid:                 water,
+			temperature:           99.0,
+			heat:                  2.0*4216*1.0 + 0.1*2.0*2257000,
+			expectedTemp:          100.0,
+			expectedVaporFraction: 0.1,
+			expectedWarnings:      1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fs := fluidSystem{
+				fluidMass:      2.0,
+				fluid:          tt.fluid,
+				temperature:    tt.temperature,
+				frozenFraction: tt.frozenFraction,
+				stepHeatIn:     []float64{tt.heat},
+			}
+			fs.commit(1.0)
+			if math.Abs(fs.temperature-tt.expectedTemp) > 1e-6 {
+				t.Errorf("expected temperature %v, got %v", tt.expectedTemp, fs.temperature)
+			}
+			if math.Abs(fs.frozenFraction-tt.expectedFrozenFraction) > 1e-6 {
+				t.Errorf("expected frozen fraction %v, got %v", tt.expectedFrozenFraction, fs.frozenFraction)
+			}
+			if math.Abs(fs.vaporFraction-tt.expectedVaporFraction) > 1e-6 {
+				t.Errorf("expected vapor fraction %v, got %v", tt.expectedVaporFraction, fs.vaporFraction)
+			}
+			if warnings := fs.popWarnings(); len(warnings) != tt.expectedWarnings {
+				t.Errorf("expected %v warnings, got %v", tt.expectedWarnings, warnings)
+			}
+		})
+	}
+}
+
+func TestFluidSystem_CommitDryHeatCapacity(t *testing.T) {
+	water, _ := getFluid(waterFluidName)
+	fs := fluidSystem{
+		fluidMass:       2.0,
+		fluid:           water,
+		dryHeatCapacity: 10000.0,
+		temperature:     20.0,
+		stepHeatIn:      []float64{1000.0},
+	}
+	fs.commit(1.0)
+	expectedTemp := 20.0 + 1000.0/(2.0*4182+10000.0)
+	if math.Abs(fs.temperature-expectedTemp) > 1e-9 {
+		t.Errorf("expected %v, got %v", expectedTemp, fs.temperature)
+	}
+
+	// a node with only dry mass, like an absorber plate
+	plate := fluidSystem{dryHeatCapacity: 10000.0, temperature: 20.0, stepHeatIn: []float64{1000.0}}
+	plate.commit(1.0)
+	if math.Abs(plate.temperature-20.1) > 1e-9 {
+		t.Errorf("expected 20.1, got %v", plate.temperature)
+	}
+}
+
+func TestAbsorberPlate(t *testing.T) {
+	fluidNode := &mockFluidSystem{}
+	ap := absorberPlate{
+		fluidSystem: fluidSystem{
+			exposedSurfaceArea: 2.0,
+			ambientTemp:        20.0,
+			ambientHTC:         10.0,
+			dryHeatCapacity:    10000.0,
+			temperature:        30.0,
+		},
+		panelArea:       2.0,
+		panelEfficiency: 0.5,
+		fluidHTC:        100.0,
+	}
+	ap.initialize(fluidNode, mockVariableIntegrator(1000.0))
+	ap.reset()
+	ap.step()
+
+	// the absorbed radiation goes to the plate, and the plate passes heat to the fluid at 0 °C
+	expectedFluidHeat := 100.0 * 2.0 * 30.0
+	if fluidNode.receivedHeat != expectedFluidHeat {
+		t.Errorf("expected %v transferred to the fluid, got %v", expectedFluidHeat, fluidNode.receivedHeat)
+	}
+	expectedNetHeat := 0.5*1000.0*2.0 - 10.0*2.0*10.0 - expectedFluidHeat
+	if heat := ap.getNetHeat(); heat != expectedNetHeat {
+		t.Errorf("expected net heat %v, got %v", expectedNetHeat, heat)
+	}
+}
@@ -0,0 +1,104 @@
+// valves route and blend flows between systems: a diverter sends a loop's flow to one of several outlets,
+// a mixing valve blends two streams, and a check valve only lets flow through in one direction
+package main
+
+import "math"
+
+// diverterValve is a three-way (or more) valve sending all of its inlet flow to the outlet at position
+type diverterValve struct {
+	component
+	flowMass variableIntegrator // kg/s; inlet flow
+	position int
+}
+
+// getOutletFlow returns the flow leaving through an outlet
+func (v *diverterValve) getOutletFlow(outlet int) variableIntegrator {
+	return func() float64 {
+		if v.position != outlet {
+			return 0.0
+		}
+		return v.flowMass()
+	}
+}
+
+// mixingValve blends two inlet streams; fraction is the share of the outlet flow taken from inlet A
+type mixingValve struct {
+	component
+	fraction   variableIntegrator
+	inletTempA variableIntegrator
+	inletTempB variableIntegrator
+}
+
+func (v mixingValve) getOutletTemp() float64 {
+	fraction := math.Max(0, math.Min(1, v.fraction()))
+	return fraction*v.inletTempA() + (1-fraction)*v.inletTempB()
+}
+
+// thermostaticFraction is the share of hot water a tempering valve takes to deliver the setpoint:
+// f = (Tₛₑₜ - T꜀)/(Tₕ - T꜀). The valve opens fully when the hot water is at or below the setpoint.
+func thermostaticFraction(setpoint float64, hotTemp float64, coldTemp float64) float64 {
+	if hotTemp <= setpoint || hotTemp <= coldTemp {
+		return 1.0
+	}
+	return math.Max(0, (setpoint-coldTemp)/(hotTemp-coldTemp))
+}
+
+// checkValve opens once the pressure across it reaches its cracking pressure, and blocks reverse flow.
+// A pump too weak to crack the valve delivers no flow.
+type checkValve struct {
+	component
+	crackingPressure float64 // Pa
+}
+
+func (v checkValve) getPressureDrop(flowVolume float64, f *fluid, temp float64) float64 {
+	if flowVolume <= 0 {
+		return 0.0
+	}
+	return v.crackingPressure
+}
+
+// diverterController routes the collector's flow to the hottest tank it can still raise: a tank at least margin colder
+// than the collector. When the collector can't raise any tank, the flow goes to the coldest one.
+type diverterController struct {
+	name          string
+	margin        float64 // K
+	collectorTemp variableIntegrator
+	tankTemps     []variableIntegrator
+	valve         *diverterValve
+}
+
+func (c *diverterController) getName() string {
+	return c.name
+}
+
+func (c *diverterController) update(time float64, timeStep float64) {
+	collectorTemp := c.collectorTemp()
+	selected, coldest := -1, 0
+	for i, tankTemp := range c.tankTemps {
+		temp := tankTemp()
+		if temp < c.tankTemps[coldest]() {
+			coldest = i
+		}
+		if collectorTemp-temp >= c.margin && (selected < 0 || temp > c.tankTemps[selected]()) {
+			selected = i
+		}
+	}
+	if selected < 0 {
+		selected = coldest
+	}
+	c.valve.position = selected
+}
+
+// getSelectedTemp is the temperature of the tank the valve currently sends flow to
+func (c *diverterController) getSelectedTemp() float64 {
+	return c.tankTemps[c.valve.position]()
+}
+
+func (c *diverterController) getState() map[string]float64 {
+	return map[string]float64{"position": float64(c.valve.position)}
+}
+
+func (c *diverterController) setState(state map[string]float64) error {
+	c.valve.position = int(state["position"])
+	return nil
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestDiverterController(t *testing.T) {
+	collectorTemp := 0.0
+	valve := &diverterValve{component{"Diverter"}, mockVariableIntegrator(0.2), 0}
+	c := diverterController{
+		name:          "DiverterController",
+		margin:        4,
+		collectorTemp: func() float64 { return collectorTemp },
+		tankTemps:     []variableIntegrator{mockVariableIntegrator(20), mockVariableIntegrator(50)},
+		valve:         valve,
+	}
+
+	tests := []struct {
+		name          string
+		collectorTemp float64
+		expected      int
+	}{
+		{name: "Raises both tanks", collectorTemp: 60, expected: 1},
+		{name: "Raises the preheat tank only", collectorTemp: 52, expected: 0},
+		{name: "Raises neither tank", collectorTemp: 15, expected: 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			collectorTemp = tt.collectorTemp
+			c.update(0, 1)
+			if valve.position != tt.expected {
+				t.Errorf("expected position %d, got %d", tt.expected, valve.position)
+			}
+			for outlet := range c.tankTemps {
+				expectedFlow := 0.0
+				if outlet == tt.expected {
+					expectedFlow = 0.2
+				}
+				if flow := valve.getOutletFlow(outlet)(); flow != expectedFlow {
+					t.Errorf("expected %v kg/s through outlet %d, got %v", expectedFlow, outlet, flow)
+				}
+			}
+		})
+	}
+}
+
+func TestMixingValve(t *testing.T) {
+	v := mixingValve{
+		component:  component{"Mixing"},
+		fraction:   mockVariableIntegrator(0.25),
+		inletTempA: mockVariableIntegrator(60),
+		inletTempB: mockVariableIntegrator(20),
+	}
+	if temp := v.getOutletTemp(); math.Abs(temp-30) > float64EqualityThreshold {
+		t.Errorf("expected 30, got %v", temp)
+	}
+	v.fraction = mockVariableIntegrator(1.5)
+	if temp := v.getOutletTemp(); math.Abs(temp-60) > float64EqualityThreshold {
+		t.Errorf("expected the fraction to be clamped to the hot inlet, got %v", temp)
+	}
+}
+
+func TestCheckValveStopsWeakPump(t *testing.T) {
+	water := fluids[waterFluidName]
+	p := pump{
+		name:          "Pump",
+		model:         curvePumpModel,
+		shutoffHead:   6,
+		maxFlowVolume: 1e-3,
+		signalType:    pwmPumpSignal,
+		signal:        mockVariableIntegrator(100),
+		loop:          []IHydraulicResistance{checkValve{component{"Check Valve"}, 20000}},
+		fluid:         water,
+		fluidTemp:     mockVariableIntegrator(20),
+	}
+	p.update(0, 1)
+	if p.flowMass <= 0 {
+		t.Errorf("expected a full speed pump to crack the valve")
+	}
+
+	// at 40% speed the pump's shutoff head is below the cracking pressure of about 2 m
+	p.signal = mockVariableIntegrator(40)
+	p.update(1, 1)
+	if p.flowMass > 1e-9 {
+		t.Errorf("expected no flow, got %v kg/s", p.flowMass)
+	}
+}
+
+func TestThermostaticFraction(t *testing.T) {
+	tests := []struct {
+		name     string
+		hotTemp  float64
+		expected float64
+	}{
+		{name: "Hot tank", hotTemp: 75, expected: 0.5},
+		{name: "Tank at setpoint", hotTemp: 45, expected: 1.0},
+		{name: "Tank below setpoint", hotTemp: 30, expected: 1.0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if fraction := thermostaticFraction(45, tt.hotTemp, 15); math.Abs(fraction-tt.expected) > float64EqualityThreshold {
+				t.Errorf("expected %v, got %v", tt.expected, fraction)
+			}
+		})
+	}
+}
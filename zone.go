@@ -0,0 +1,35 @@
+// thermal zone: a lumped RC model of a room. The room's air and furnishings store heat, the envelope loses heat
+// to the outdoors, and occupants and appliances add internal gains. Systems in the room can use the zone as their
+// ambient, so the heat they lose warms the room and the room follows the outdoor temperature.
+package main
+
+// specific heat of air per volume, ρc
+const airVolumetricHeatCapacity = 1.2 * 1005 // J/(m^3*K)
+
+type thermalZone struct {
+	fluidSystem           // no fluid mass; the air and furnishings are dryHeatCapacity
+	envelopeUA    float64 // W/K; walls, windows and infiltration to the outdoors
+	internalGains variableIntegrator
+	outdoorTemp   variableIntegrator
+}
+
+func (z *thermalZone) initialize() {
+	z.heatInComponents = []IComponent{
+		heatRateComponent{
+			component: component{
+				name: "Internal Gains",
+			},
+			heat: z.internalGains,
+		},
+	}
+	z.heatOutComponents = []IComponent{
+		conductanceHeatComponent{
+			component: component{
+				name: "Envelope Heat Loss",
+			},
+			conductance: z.envelopeUA,
+			currentTemp: func() float64 { return z.temperature },
+			outputTemp:  z.outdoorTemp,
+		},
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+func TestThermalZone(t *testing.T) {
+	zone := thermalZone{
+		fluidSystem: fluidSystem{
+			name:            "IndoorZone",
+			dryHeatCapacity: 1e5,
+			temperature:     20,
+		},
+		envelopeUA:    50,
+		internalGains: mockVariableIntegrator(200),
+		outdoorTemp:   mockVariableIntegrator(0),
+	}
+	zone.initialize()
+	tank := fluidSystem{
+		name:               "StorageTank",
+		exposedSurfaceArea: 2,
+		ambientHTC:         5,
+		ambient:            &zone,
+		fluidMass:          100,
+		temperature:        60,
+	}
+	tank.addEnvironmentalConvectionHeatLossComponent()
+
+	systems := []ISystem{&tank, &zone}
+	for _, sys := range systems {
+		sys.reset()
+	}
+	for _, sys := range systems {
+		sys.step()
+	}
+	for _, sys := range systems {
+		sys.commit(60)
+	}
+
+	// the tank loses hA(T - Tᵣₒₒₘ) = 400 W to the room, which also gains 200 W and loses 1000 W through the envelope
+	if math.Abs(zone.getNetHeat()-(400+200-1000)) > 1e-6 {
+		t.Errorf("expected the zone to store -400 W, got %v W", zone.getNetHeat())
+	}
+	if math.Abs(zone.temperature-(20-400*60/1e5)) > 1e-6 {
+		t.Errorf("unexpected zone temperature %v", zone.temperature)
+	}
+	if tank.getAmbientTemp() != zone.temperature {
+		t.Errorf("expected the tank's ambient to follow the zone, got %v", tank.getAmbientTemp())
+	}
+}